@@ -0,0 +1,189 @@
+//go:build !wasm
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+func init() {
+	// runAPIfunc is defined as a dangling var in main.
+	// it gets attached IFF !wasm, as browsers don't
+	// take kindly to us listening on sockets.
+	runAPIfunc = runAPI
+}
+
+// runAPI listens on addr, and serves the HTTP control API from it in the background.
+// Every action takes a POST, and answers with the resulting status (or an error) as JSON:
+//
+//	GET  /status
+//	POST /start, /stop, /pause, /restart, /mute, /pan
+//	POST /tempo?bpm=96 or /tempo?delta=-5
+//	POST /signature?ts=3/4
+//	POST /pattern?beats=13
+//	POST /sound?name=Cowbell
+//	POST /command?line=tempo+%2B5 (any line control.Command takes)
+//	GET  /events (WebSocket stream of JSON controlEvents)
+//	GET  /sync (clock and state, for followers; see follow.go)
+//
+// Web pages the user happens to visit could send it requests too, so all but /sync are
+// refused from web pages of other origins (see apiCheckOrigin).
+func runAPI(addr string) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	s := &http.Server{
+		Handler:           apiMux(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	controlClosers = append(controlClosers, s)
+	go s.Serve(l)
+	return nil
+}
+
+// apiMux returns the API's routes (see runAPI).
+func apiMux() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /status", apiStatus)
+	mux.HandleFunc("GET /sync", apiSync)
+	mux.HandleFunc("POST /start", apiAction(func(*http.Request) error { return ctl.Start() }))
+	mux.HandleFunc("POST /stop", apiAction(func(*http.Request) error { return ctl.Stop() }))
+	mux.HandleFunc("POST /pause", apiAction(func(*http.Request) error { return ctl.Pause() }))
+	mux.HandleFunc("POST /restart", apiAction(func(*http.Request) error { return ctl.Restart() }))
+	mux.HandleFunc("POST /mute", apiAction(func(*http.Request) error { ctl.Mute(); return nil }))
+	mux.HandleFunc("POST /pan", apiAction(func(*http.Request) error { ctl.Pan(); return nil }))
+	mux.HandleFunc("POST /tempo", apiAction(apiTempo))
+	mux.HandleFunc("POST /signature", apiAction(func(r *http.Request) error { return ctl.SetSignature(r.FormValue("ts")) }))
+	mux.HandleFunc("POST /pattern", apiAction(func(r *http.Request) error { return ctl.SetPattern(r.FormValue("beats")) }))
+	mux.HandleFunc("POST /sound", apiAction(func(r *http.Request) error { return ctl.SetSound(r.FormValue("name")) }))
+	mux.HandleFunc("POST /marking", apiAction(func(r *http.Request) error { return ctl.SetMarking(r.FormValue("name")) }))
+	mux.HandleFunc("POST /style", apiAction(func(r *http.Request) error { return ctl.SetStyle(r.FormValue("name")) }))
+	mux.HandleFunc("POST /command", apiAction(func(r *http.Request) error { return ctl.Command(r.FormValue("line")) }))
+	// websocket.Server, unlike websocket.Handler, doesn't insist on an Origin, so
+	// non-browser dashboards can connect too, but browsers must be on our page.
+	mux.Handle("GET /events", websocket.Server{
+		Handler:   apiEvents,
+		Handshake: func(_ *websocket.Config, r *http.Request) error { return apiCheckOrigin(r) },
+	})
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/sync" {
+			if err := apiCheckOrigin(r); err != nil {
+				apiJSON(w, http.StatusForbidden, map[string]string{"error": err.Error()})
+				return
+			}
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// apiCheckOrigin returns an error if r came from a web page elsewhere. Browsers say where
+// from in Origin, which must be us, and other clients don't say. Host must be an address, or
+// localhost, so a page can't rename itself to us by DNS (rebinding) to pass as the same origin.
+func apiCheckOrigin(r *http.Request) error {
+	host, _, err := net.SplitHostPort(r.Host)
+	if err != nil {
+		host = r.Host
+	}
+	if host != "localhost" && net.ParseIP(host) == nil {
+		return fmt.Errorf("refusing host %q: use an address, or localhost", r.Host)
+	}
+	if origin := r.Header.Get("Origin"); origin != "" {
+		if u, err := url.Parse(origin); err != nil || u.Host != r.Host {
+			return fmt.Errorf("refusing a request from %s", origin)
+		}
+	}
+	return nil
+}
+
+// apiAction wraps an action into a handler that answers with the resulting status, or the error.
+func apiAction(action func(*http.Request) error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := action(r); err != nil {
+			ctl.reportError(err)
+			apiJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		apiStatus(w, r)
+	}
+}
+
+// apiStatus answers with the current status.
+func apiStatus(w http.ResponseWriter, r *http.Request) {
+	apiJSON(w, http.StatusOK, ctl.Status())
+}
+
+// apiSync answers followers, who may well be WASM builds from elsewhere.
+func apiSync(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	apiJSON(w, http.StatusOK, newSyncReply(ctl))
+}
+
+// apiTempo sets the tempo from "bpm", or nudges it by "delta", or by "percent".
+func apiTempo(r *http.Request) error {
+	if bpm := r.FormValue("bpm"); bpm != "" {
+		n, err := strconv.ParseFloat(bpm, 64)
+		if err != nil {
+			return fmt.Errorf("invalid bpm %q", bpm)
+		}
+		return ctl.SetTempo(n)
+	}
+	if delta := r.FormValue("delta"); delta != "" {
+		n, err := strconv.ParseFloat(delta, 64)
+		if err != nil {
+			return fmt.Errorf("invalid delta %q", delta)
+		}
+		return ctl.NudgeTempo(n)
+	}
+	if percent := r.FormValue("percent"); percent != "" {
+		n, err := strconv.ParseFloat(percent, 64)
+		if err != nil {
+			return fmt.Errorf("invalid percent %q", percent)
+		}
+		return ctl.NudgeTempoPercent(n)
+	}
+	return fmt.Errorf("bpm, delta, or percent required")
+}
+
+// apiEvents streams controlEvents to the socket until it goes away.
+func apiEvents(ws *websocket.Conn) {
+	events := ctl.Subscribe()
+	defer ctl.Unsubscribe(events)
+
+	// We don't expect to hear anything, but reading is how we find out they left.
+	gone := make(chan struct{})
+	go func() {
+		defer close(gone)
+		var junk string
+		for websocket.Message.Receive(ws, &junk) == nil {
+		}
+	}()
+
+	for {
+		select {
+		case <-gone:
+			return
+		case e := <-events:
+			if err := websocket.JSON.Send(ws, e); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// apiJSON writes v as the JSON response, with code.
+func apiJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
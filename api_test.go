@@ -0,0 +1,183 @@
+//go:build !wasm
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+func TestAPICheckOrigin(t *testing.T) {
+	tests := []struct {
+		host, origin string
+		ok           bool
+	}{
+		{"localhost:8080", "", true},
+		{"127.0.0.1:8080", "", true},
+		{"[::1]:8080", "", true},
+		{"localhost:8080", "http://localhost:8080", true},
+		{"192.168.1.10:8080", "http://192.168.1.10:8080", true},
+		{"localhost:8080", "http://localhost:3000", false},
+		{"localhost:8080", "https://evil.example", false},
+		{"localhost:8080", "null", false},
+		{"evil.example:8080", "", false},
+		{"evil.example:8080", "http://evil.example:8080", false}, // DNS rebinding
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodPost, "http://"+tt.host+"/start", nil)
+		if tt.origin != "" {
+			r.Header.Set("Origin", tt.origin)
+		}
+		if err := apiCheckOrigin(r); (err == nil) != tt.ok {
+			t.Errorf("apiCheckOrigin(Host %s, Origin %q) = %v, want ok %v", tt.host, tt.origin, err, tt.ok)
+		}
+	}
+}
+
+func TestAPIRefusesOtherOrigins(t *testing.T) {
+	ctl = newTestControl()
+	mux := apiMux()
+
+	for _, path := range []string{"/start", "/tempo?bpm=96", "/command?line=tempo+96"} {
+		r := httptest.NewRequest(http.MethodPost, "http://localhost:8080"+path, nil)
+		r.Header.Set("Origin", "https://evil.example")
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, r)
+		if w.Code != http.StatusForbidden {
+			t.Errorf("POST %s from another origin = %d, want %d", path, w.Code, http.StatusForbidden)
+		}
+	}
+	if s := ctl.Status(); s.Running || s.Tempo != 60 {
+		t.Errorf("requests from another origin changed the status to %+v", s)
+	}
+
+	// Followers may be anywhere
+	r := httptest.NewRequest(http.MethodGet, "http://conductor.local:8080/sync", nil)
+	r.Header.Set("Origin", "https://elsewhere.example")
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Errorf("GET /sync from another origin = %d, want %d", w.Code, http.StatusOK)
+	}
+}
+
+func TestAPIRoutes(t *testing.T) {
+	ctl = newTestControl()
+	defer ctl.Stop()
+	mux := apiMux()
+
+	// In order, as each builds on the last
+	tests := []struct {
+		path  string
+		code  int
+		check func(controlStatus) bool
+	}{
+		{"/start", http.StatusOK, func(s controlStatus) bool { return s.Running }},
+		{"/pause", http.StatusOK, func(s controlStatus) bool { return s.Paused }},
+		{"/pause", http.StatusOK, func(s controlStatus) bool { return !s.Paused }},
+		{"/tempo?bpm=96", http.StatusOK, func(s controlStatus) bool { return s.Tempo == 96 }},
+		{"/tempo?delta=-5.5", http.StatusOK, func(s controlStatus) bool { return s.Tempo == 90.5 }},
+		{"/tempo?percent=10", http.StatusOK, func(s controlStatus) bool { return s.Tempo == 99.6 }},
+		{"/tempo?bpm=fast", http.StatusBadRequest, nil},
+		{"/tempo", http.StatusBadRequest, nil},
+		{"/signature?ts=3/4", http.StatusOK, func(s controlStatus) bool { return s.Signature == "3/4" }},
+		{"/signature?ts=three", http.StatusBadRequest, nil},
+		{"/pattern?beats=13", http.StatusOK, func(s controlStatus) bool { return s.Pattern == "13" }},
+		{"/sound?name=Cowbell", http.StatusOK, func(s controlStatus) bool { return s.Sound == "Cowbell" }},
+		{"/sound?name=Kazoo", http.StatusBadRequest, nil},
+		{"/marking?name=Allegro", http.StatusOK, func(s controlStatus) bool { return s.Tempo == 138 }},
+		{"/style?name=waltz", http.StatusOK, func(s controlStatus) bool { return s.Tempo == 90 && s.Pattern == "1>23" }},
+		{"/command?line=tempo+%2B5", http.StatusOK, func(s controlStatus) bool { return s.Tempo == 95 }},
+		{"/command?line=dance", http.StatusBadRequest, nil},
+		{"/mute", http.StatusOK, func(s controlStatus) bool { return s.Muted }},
+		{"/pan", http.StatusOK, func(s controlStatus) bool { return s.Panned }},
+		{"/restart", http.StatusOK, func(s controlStatus) bool { return s.Running }},
+		{"/stop", http.StatusOK, func(s controlStatus) bool { return !s.Running }},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "http://localhost:8080"+tt.path, nil))
+		if w.Code != tt.code {
+			t.Errorf("POST %s = %d, want %d: %s", tt.path, w.Code, tt.code, w.Body)
+			continue
+		}
+		if tt.check == nil {
+			continue
+		}
+		var s controlStatus
+		if err := json.NewDecoder(w.Body).Decode(&s); err != nil {
+			t.Errorf("POST %s answered %v", tt.path, err)
+		} else if !tt.check(s) {
+			t.Errorf("POST %s answered %+v", tt.path, s)
+		}
+	}
+
+	// Only POST changes anything
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "http://localhost:8080/start", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET /start = %d, want %d", w.Code, http.StatusMethodNotAllowed)
+	}
+}
+
+func TestAPISync(t *testing.T) {
+	ctl = newTestControl()
+	defer ctl.Stop()
+	ctl.SetTempo(120)
+	ctl.Start()
+	time.Sleep(50 * time.Millisecond) // for the first downbeat
+
+	w := httptest.NewRecorder()
+	before := time.Now()
+	apiMux().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "http://localhost:8080/sync", nil))
+	var r syncReply
+	if err := json.NewDecoder(w.Body).Decode(&r); err != nil {
+		t.Fatal(err)
+	}
+	if w.Header().Get("Access-Control-Allow-Origin") != "*" {
+		t.Error("/sync isn't open to followers from elsewhere")
+	}
+	if r.Now.Before(before) || r.Now.After(time.Now()) {
+		t.Errorf("/sync's now is %s, not when it answered", r.Now)
+	}
+	if r.Period != 500*time.Millisecond || r.Beats != 4 || !r.Status.Running {
+		t.Errorf("/sync = %+v, want a running 4/4 at 120", r)
+	}
+	if r.Downbeat.IsZero() || r.Downbeat.After(r.Now) {
+		t.Errorf("/sync's downbeat is %s, now %s", r.Downbeat, r.Now)
+	}
+}
+
+func TestAPIEvents(t *testing.T) {
+	ctl = newTestControl()
+	s := httptest.NewServer(apiMux())
+	defer s.Close()
+	ws := "ws" + strings.TrimPrefix(s.URL, "http") + "/events"
+
+	if _, err := websocket.Dial(ws, "", "https://evil.example"); err == nil {
+		t.Error("/events let another origin in")
+	}
+
+	conn, err := websocket.Dial(ws, "", s.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	time.Sleep(50 * time.Millisecond) // to be subscribed
+	ctl.SetTempo(96)
+
+	var e controlEvent
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	if err := websocket.JSON.Receive(conn, &e); err != nil {
+		t.Fatal(err)
+	}
+	if e.Type != "state" || e.Status == nil || e.Status.Tempo != 96 {
+		t.Errorf("/events sent %+v, want the state at 96 bpm", e)
+	}
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/cognusion/go-gnome"
+	"github.com/cognusion/go-recyclable/v2"
+)
+
+// clicker is what keeps the time, and maybe clicks it: a gnome, or a nullGnome
+// when there's no audio to be had (or wanted).
+type clicker interface {
+	Start()
+	Stop()
+	Restart()
+	Pause() // toggle
+	IsPaused() bool
+	Change(tempo float64) // BPM, to a tenth
+	Mute()                // toggle
+	Pan()                 // toggle
+	Close()
+	ReplaceStreamerFromBuffer(*recyclable.Buffer) error
+	SetTickFilter(func(int) bool) error
+	Signature() *gnome.TimeSignature
+}
+
+// audioGnome is a gnome, as a clicker. A gnome only does whole BPM, so for a tempo in
+// between (e.g. 92.5), it's changed beat by beat between the whole BPM either side,
+// whichever keeps the beats closest to where the exact tempo would land them. Any one
+// beat may be a few milliseconds off, but they don't drift.
+type audioGnome struct {
+	*gnome.Gnome
+	mu    sync.Mutex
+	tempo float64       // exactly
+	whole int32         // what the gnome is on
+	ahead time.Duration // how far ahead of the exact tempo the beats have landed
+}
+
+// Change changes the tempo.
+func (a *audioGnome) Change(tempo float64) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.tempo = tempo
+	a.whole = int32(math.Round(tempo))
+	a.ahead = 0
+	a.Gnome.Change(a.whole)
+}
+
+// tick picks the whole tempo for the next beat. The gnome's tick function should call it.
+func (a *audioGnome) tick() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.tempo == math.Trunc(a.tempo) {
+		return
+	}
+
+	var whole int32
+	whole, a.ahead = ditherTempo(a.tempo, a.whole, a.ahead)
+	if whole != a.whole {
+		a.whole = whole
+		a.Gnome.Change(whole)
+	}
+}
+
+// ditherTempo returns the whole BPM for the next beat, for tempo, and how far ahead of
+// it the beats have landed, given that the last beat was at whole, and ahead before it.
+func ditherTempo(tempo float64, whole int32, ahead time.Duration) (int32, time.Duration) {
+	ahead += tempoPeriod(tempo) - tempoPeriod(float64(whole))
+	whole = int32(math.Floor(tempo)) // slower, to fall back
+	if ahead <= 0 {
+		whole++ // faster, to catch up
+	}
+	return whole, ahead
+}
+
+// Signature returns the gnome's time signature.
+func (a *audioGnome) Signature() *gnome.TimeSignature {
+	return a.TS
+}
+
+// nullGnome is a gnome without the audio: it keeps time and calls its tick function
+// same as a gnome does, but clicks into nothing. Goro-safe.
+type nullGnome struct {
+	ts     *gnome.TimeSignature // whose Tempo is only ever the nearest whole BPM
+	period atomic.Int64         // of a beat, exactly, as a time.Duration
+	tf     func(int)
+	mu     sync.Mutex
+	filter func(int) bool
+	paused bool
+	stop   chan struct{}
+}
+
+// newNullGnome returns a nullGnome in beats/4 at tempo, that calls tf every tick.
+func newNullGnome(beats int32, tempo float64, tf func(int)) *nullGnome {
+	ts := new(gnome.TimeSignature)
+	ts.FromString(fmt.Sprintf("%d/4", beats))
+	n := &nullGnome{ts: ts, tf: tf}
+	n.Change(tempo)
+	return n
+}
+
+// Start starts ticking, the first beat right away, and unpaused.
+func (n *nullGnome) Start() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.stop != nil {
+		return
+	}
+	n.paused = false
+	n.stop = make(chan struct{})
+	go n.run(n.stop)
+}
+
+// run ticks until stop is closed. Each beat is scheduled from the last one,
+// rather than from whenever we got around to it, so we don't drift.
+func (n *nullGnome) run(stop chan struct{}) {
+	var (
+		beat int
+		next = time.Now()
+		t    = time.NewTimer(0)
+	)
+	defer t.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-t.C:
+		}
+		next = next.Add(time.Duration(n.period.Load()))
+		t.Reset(time.Until(next))
+
+		n.mu.Lock()
+		paused, filter := n.paused, n.filter
+		n.mu.Unlock()
+		if paused {
+			continue
+		}
+		beat = beat%int(n.ts.Beats.Load()) + 1
+		if filter != nil {
+			// As the gnome does, though there's nothing to hear
+			filter(beat)
+		}
+		n.tf(beat)
+	}
+}
+
+// Stop stops ticking.
+func (n *nullGnome) Stop() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.stop != nil {
+		close(n.stop)
+		n.stop = nil
+	}
+}
+
+// Restart starts ticking over, from the top.
+func (n *nullGnome) Restart() {
+	n.Stop()
+	n.Start()
+}
+
+// Pause toggles pause/resume.
+func (n *nullGnome) Pause() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.paused = !n.paused
+}
+
+// IsPaused returns true if we're paused.
+func (n *nullGnome) IsPaused() bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.paused
+}
+
+// Change changes the tempo, from the next beat.
+func (n *nullGnome) Change(tempo float64) {
+	n.ts.Tempo.Store(int32(math.Round(tempo)))
+	n.period.Store(int64(tempoPeriod(tempo)))
+}
+
+// Mute is a noop, as there's nothing to hear.
+func (n *nullGnome) Mute() {}
+
+// Pan is a noop, as there's nothing to hear.
+func (n *nullGnome) Pan() {}
+
+// Close stops ticking.
+func (n *nullGnome) Close() {
+	n.Stop()
+}
+
+// ReplaceStreamerFromBuffer just closes b, as there's nothing to hear.
+func (n *nullGnome) ReplaceStreamerFromBuffer(b *recyclable.Buffer) error {
+	b.Close()
+	return nil
+}
+
+// SetTickFilter has f called before every tick, as the gnome does, though there's nothing to hear.
+func (n *nullGnome) SetTickFilter(f func(int) bool) error {
+	if f == nil {
+		return fmt.Errorf("nil tick filter")
+	}
+	n.mu.Lock()
+	n.filter = f
+	n.mu.Unlock()
+	return nil
+}
+
+// Signature returns our time signature.
+func (n *nullGnome) Signature() *gnome.TimeSignature {
+	return n.ts
+}
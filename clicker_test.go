@@ -0,0 +1,26 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDitherTempo(t *testing.T) {
+	tests := []float64{92.5, 60.1, 119.9, 20.3, 299.7}
+	for _, tempo := range tests {
+		// At most a beat's worth of difference between the whole BPM either side, off
+		slack := tempoPeriod(float64(int(tempo))) - tempoPeriod(float64(int(tempo)+1))
+		whole, ahead := int32(tempo+0.5), time.Duration(0)
+		var played time.Duration
+		for n := 1; n <= 1000; n++ {
+			played += tempoPeriod(float64(whole))
+			whole, ahead = ditherTempo(tempo, whole, ahead)
+			if whole != int32(tempo) && whole != int32(tempo)+1 {
+				t.Fatalf("ditherTempo(%v) picked %d BPM", tempo, whole)
+			}
+			if off := played - time.Duration(n)*tempoPeriod(tempo); off.Abs() > slack {
+				t.Fatalf("ditherTempo(%v) is %s off after %d beats, more than %s", tempo, off, n, slack)
+			}
+		}
+	}
+}
@@ -0,0 +1,840 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode"
+
+	"github.com/cognusion/go-gnome"
+)
+
+const (
+	// tapTimeout is how long between taps before tap tempo starts over.
+	tapTimeout = 2 * time.Second
+	// tapCount is how many of the latest taps tap tempo averages.
+	tapCount = 5
+)
+
+// controlStatus is a snapshot of what the gnome is up to, as seen by the control surfaces.
+type controlStatus struct {
+	Started   bool    `json:"started"`
+	Running   bool    `json:"running"`
+	Paused    bool    `json:"paused"`
+	Muted     bool    `json:"muted"`
+	Panned    bool    `json:"panned"`
+	CountIn   int     `json:"countIn"`   // bars, on Start and Restart
+	CountOnly int     `json:"countOnly"` // the last this many beats of them click, or all if zero
+	GapPlay   int     `json:"gapPlay"`   // bars to click, then
+	GapSilent int     `json:"gapSilent"` // bars not to, or zero for no gaps (see gap.go)
+	GapRandom float64 `json:"gapRandom"` // percent of beats to drop at random
+
+	// The practice timer, in seconds (see practice.go)
+	PracticeFor   int     `json:"practiceFor,omitempty"`   // to play for, or
+	PracticeBars  int     `json:"practiceBars,omitempty"`  // bars to play
+	PracticeBreak int     `json:"practiceBreak,omitempty"` // between goes
+	PracticeLeft  int     `json:"practiceLeft,omitempty"`  // of this go, if timed
+	BreakLeft     int     `json:"breakLeft,omitempty"`     // of the break, if on one
+	Tempo         float64 `json:"tempo"`                   // BPM, to a tenth
+	Signature     string  `json:"signature"`
+	Pattern       string  `json:"pattern"`
+	Sound         string  `json:"sound"`
+}
+
+// String is the signature and tempo, and the tempo marking, e.g. "4/4 @ 92.5 bpm (Andante)".
+func (s controlStatus) String() string {
+	return fmt.Sprintf("%s @ %s bpm (%s)", s.Signature, fmtTempo(s.Tempo), markingFor(s.Tempo))
+}
+
+// controlEvent is what subscribers are sent on every tick, state change, practice timer going off, or error.
+type controlEvent struct {
+	Type    string         `json:"type"` // beat, state, practice (the timer went off), or error
+	Time    time.Time      `json:"time"`
+	Beat    int            `json:"beat,omitempty"`
+	Measure int            `json:"measure,omitempty"` // zero while counting in
+	CountIn int            `json:"countIn,omitempty"` // where the beat is in the count-in, counting down to one
+	Silent  bool           `json:"silent,omitempty"`  // the beat is in a gap (see gap.go)
+	Unheard bool           `json:"unheard,omitempty"` // the beat didn't click: it's off, dropped, or in a gap
+	Status  *controlStatus `json:"status,omitempty"`
+	Error   string         `json:"error,omitempty"`
+}
+
+// control is the one set of actions every control surface (GUI, TUI, API, etc.) shares,
+// so that they all agree on what state the gnome is in. Goro-safe.
+type control struct {
+	g         clicker
+	ts        *gnome.TimeSignature // g's, whose Tempo is only ever the nearest whole BPM
+	mu        sync.Mutex           // guards the state, and serializes calls to g
+	period    atomic.Int64         // of a beat at tempo, exactly, as a time.Duration
+	tempo     float64              // BPM, to a tenth
+	started   bool
+	running   bool
+	muted     bool
+	panned    bool
+	signature string
+	pattern   string
+	beats     map[int]beatState // pattern, parsed (see patternBeats)
+	sound     string
+	taps      []time.Time // recent taps, for tap tempo
+
+	// The count-in (see countin.go), under mu; the count-in going is under emu, below.
+	countInBars  int // to count in, on Start and Restart
+	countInBeats int // if any, only the last this many beats of the count-in click
+
+	voice       *voice // to count out loud, if any (see voice.go)
+	playing     string // the sound the gnome has, or empty if it's a beat of voice
+	next        int    // the beat the gnome has queued (see queueBeat)
+	nextCountIn bool   // and if it's in the count-in
+
+	// The practice timer (see practice.go), under mu; its bars are under emu, below.
+	practiceFor   time.Duration // to play for, if there's a timer
+	practiceBreak time.Duration // between goes, if any
+	practiced     time.Duration // so far, not counting since practiceSince
+	practiceSince time.Time     // when the gnome started or resumed, if it's playing
+	practiceGen   int           // so timers set before a change don't go off after it
+	breakUntil    time.Time     // when the break ends, if on one
+
+	history      string           // the practice log's path, if we're keeping one (see history.go)
+	session      *practiceSession // the one going, if any
+	sessionSince time.Time        // when it last started or stopped pausing
+
+	// emu guards the event side separately, as ticks arrive from the gnome's goro
+	// and g may well wait on that goro while we hold mu.
+	emu       sync.Mutex
+	measure   int
+	downbeat  time.Time     // when the last measure started
+	beatAt    time.Time     // when the last beat was due (see beatDue)
+	beatFrom  time.Time     // when the schedule started, with a beat
+	beatsFrom int64         // beats due since
+	beatEvery time.Duration // the period the schedule keeps
+
+	starts    int          // (re)starts and stops, so a beat queued for before one isn't played after
+	unheard   bool         // the tick filter didn't let the last beat click
+	accenting bool         // the pattern has accents, so every beat is queued (see queueBeat)
+	pending   *pendingBeat // the next beat, for the queuer to queue, if it's not yet
+	queuing   bool         // the queuer's running
+	subs      map[chan controlEvent]struct{}
+
+	// The count-in going, under emu, as tick counts it down (see countin.go).
+	countLeft int // beats yet to tick
+	countOnly int // countInBeats, for the count-in going
+	counting  int // the last beat's place in the count-in (see countInTick)
+
+	// The gap trainer, under emu, as the tick filter keeps it (see gap.go).
+	gapPlay   int     // bars to click, then
+	gapSilent int     // bars not to
+	gapRandom float64 // percent of beats to drop
+	gapBar    int     // the bar the tick filter is in, counting from one
+	gapQuiet  bool    // the last beat was in a silent bar
+
+	// The practice timer's bars, under emu, as tick counts them (see practice.go).
+	practiceBars int // to play, if there's a timer
+
+	// onChange, if set, is called after every state change, e.g. so a UI can redraw.
+	onChange func(controlStatus)
+	// onPracticeDone, if set, is called with a message when the practice timer goes off.
+	onPracticeDone func(string)
+}
+
+// newControl returns a control for g, seeded from the global tunables.
+// g's tick function should call tick.
+func newControl(g clicker) *control {
+	c := &control{
+		g:             g,
+		ts:            g.Signature(),
+		tempo:         tempoBPM,
+		signature:     fmt.Sprintf("%d/4", beatsPerMeasure),
+		pattern:       beatString(beatsPerMeasure),
+		beats:         patternBeats(beatString(beatsPerMeasure)),
+		sound:         startSound,
+		countInBars:   countInBars,
+		countInBeats:  countInBeats,
+		voice:         countVoice,
+		playing:       startSound,
+		next:          1,
+		gapPlay:       gapPlay,
+		gapSilent:     gapSilent,
+		gapRandom:     gapRandom,
+		practiceFor:   practiceFor,
+		practiceBreak: practiceBreak,
+		practiceBars:  practiceBars,
+		history:       historyPath,
+		subs:          make(map[chan controlEvent]struct{}),
+	}
+	c.period.Store(int64(tempoPeriod(tempoBPM)))
+	return c
+}
+
+// Period returns how long a beat is at the tempo, exactly. Unlike Status, it doesn't
+// wait on mu, so it's fine to call from the tick function.
+func (c *control) Period() time.Duration {
+	return time.Duration(c.period.Load())
+}
+
+// Status returns a snapshot of the current state.
+func (c *control) Status() controlStatus {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.status()
+}
+
+// status is Status for callers already holding c.mu.
+func (c *control) status() controlStatus {
+	c.emu.Lock()
+	play, silent, random, bars := c.gapPlay, c.gapSilent, c.gapRandom, c.practiceBars
+	c.emu.Unlock()
+	var breakLeft time.Duration
+	if !c.breakUntil.IsZero() {
+		breakLeft = max(time.Until(c.breakUntil), 0)
+	}
+	return controlStatus{
+		Started:   c.started,
+		Running:   c.running,
+		Paused:    c.g.IsPaused(),
+		Muted:     c.muted,
+		Panned:    c.panned,
+		CountIn:   c.countInBars,
+		CountOnly: c.countInBeats,
+		GapPlay:   play,
+		GapSilent: silent,
+		GapRandom: random,
+
+		PracticeFor:   int(c.practiceFor / time.Second),
+		PracticeBars:  bars,
+		PracticeBreak: int(c.practiceBreak / time.Second),
+		PracticeLeft:  int((c.practiceLeft() + time.Second - 1) / time.Second),
+		BreakLeft:     int((breakLeft + time.Second - 1) / time.Second),
+		Tempo:         c.tempo,
+		Signature:     c.signature,
+		Pattern:       c.pattern,
+		Sound:         c.sound,
+	}
+}
+
+// Start starts the gnome, or restarts it if it has been started before.
+func (c *control) Start() error {
+	c.mu.Lock()
+	if c.running {
+		c.mu.Unlock()
+		return fmt.Errorf("already running")
+	}
+	c.startCountIn()
+	if c.started {
+		c.g.Restart()
+	} else {
+		c.g.Start()
+	}
+	c.started = true
+	c.running = true
+	c.practiced = 0
+	c.practiceGo()
+	c.sessionStart()
+	c.mu.Unlock()
+
+	c.resetMeasure()
+
+	c.changed()
+	return nil
+}
+
+// Stop stops the gnome.
+func (c *control) Stop() error {
+	c.mu.Lock()
+	if !c.running && !c.breakUntil.IsZero() {
+		// Call off the practice timer's break instead.
+		c.breakUntil = time.Time{}
+		c.practiceGen++
+		c.mu.Unlock()
+		c.changed()
+		return nil
+	}
+	if !c.running {
+		c.mu.Unlock()
+		return fmt.Errorf("not running")
+	}
+	c.sessionEnd()
+	c.g.Stop()
+	c.stopCountIn()
+	c.running = false
+	c.practiceHalt()
+	c.mu.Unlock()
+
+	c.changed()
+	return nil
+}
+
+// Restart stops the gnome if needed, and starts it again from the top.
+func (c *control) Restart() error {
+	c.mu.Lock()
+	if !c.started {
+		c.mu.Unlock()
+		return fmt.Errorf("not started")
+	}
+	if c.running {
+		if c.g.IsPaused() {
+			// Back from the pause, as the top is never paused.
+			c.sessionPause(false)
+		}
+		c.g.Stop()
+	}
+	c.startCountIn()
+	c.g.Restart()
+	c.running = true
+	c.practiced = 0
+	c.practiceGo()
+	c.sessionStart()
+	c.mu.Unlock()
+
+	c.resetMeasure()
+
+	c.changed()
+	return nil
+}
+
+// Pause toggles pause/resume.
+func (c *control) Pause() error {
+	c.mu.Lock()
+	if !c.running {
+		c.mu.Unlock()
+		return fmt.Errorf("not running")
+	}
+	c.g.Pause()
+	if c.g.IsPaused() {
+		c.practiceHalt()
+	} else {
+		c.practiceGo()
+	}
+	c.sessionPause(c.g.IsPaused())
+	c.mu.Unlock()
+
+	c.changed()
+	return nil
+}
+
+// Mute toggles mute/unmute.
+func (c *control) Mute() {
+	c.mu.Lock()
+	c.g.Mute()
+	c.muted = !c.muted
+	c.mu.Unlock()
+
+	c.changed()
+}
+
+// Pan toggles pan/unpan.
+func (c *control) Pan() {
+	c.mu.Lock()
+	c.g.Pan()
+	c.panned = !c.panned
+	c.mu.Unlock()
+
+	c.changed()
+}
+
+// SetTempo sets the tempo to bpm, rounded to a tenth.
+func (c *control) SetTempo(bpm float64) error {
+	c.mu.Lock()
+	err := c.setTempo(bpm)
+	c.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	c.changed()
+	return nil
+}
+
+// NudgeTempo moves the tempo by delta, stopping at the bounds (see checkTempo).
+func (c *control) NudgeTempo(delta float64) error {
+	c.mu.Lock()
+	err := c.nudgeTempo(delta)
+	c.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	c.changed()
+	return nil
+}
+
+// NudgeTempoPercent moves the tempo by percent of itself, and by at least a tenth of a
+// BPM, stopping at the bounds (see checkTempo).
+func (c *control) NudgeTempoPercent(percent float64) error {
+	c.mu.Lock()
+	delta := roundTempo(c.tempo * percent / 100)
+	switch {
+	case delta == 0 && percent > 0:
+		delta = 0.1
+	case delta == 0 && percent < 0:
+		delta = -0.1
+	}
+	err := c.nudgeTempo(delta)
+	c.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	c.changed()
+	return nil
+}
+
+// setTempo sets the tempo to bpm, rounded to a tenth, if it's in bounds. Call it holding mu.
+func (c *control) setTempo(bpm float64) error {
+	bpm = roundTempo(bpm)
+	if err := checkTempo(bpm); err != nil {
+		return err
+	}
+	c.tempo = bpm
+	c.period.Store(int64(tempoPeriod(bpm)))
+	c.g.Change(bpm)
+	c.sessionChange()
+	return nil
+}
+
+// nudgeTempo moves the tempo by delta, as far as the bounds allow, only complaining if
+// it's already there. Call it holding mu.
+func (c *control) nudgeTempo(delta float64) error {
+	bpm := roundTempo(min(max(c.tempo+delta, tempoMin), tempoMax))
+	if bpm == c.tempo && delta != 0 {
+		return checkTempo(roundTempo(c.tempo + delta))
+	}
+	return c.setTempo(bpm)
+}
+
+// checkTempo returns an error if bpm is out of bounds (see tempoMin and tempoMax). Every
+// tempo change, from everywhere, comes through here.
+func checkTempo(bpm float64) error {
+	if !(bpm >= tempoMin && bpm <= tempoMax) { // NaN too
+		return fmt.Errorf("tempo must be %s-%s, not %s", fmtTempo(tempoMin), fmtTempo(tempoMax), fmtTempo(bpm))
+	}
+	return nil
+}
+
+// roundTempo rounds bpm to the tenth, which is as fine as tempos go.
+func roundTempo(bpm float64) float64 {
+	return math.Round(bpm*10) / 10
+}
+
+// fmtTempo formats bpm without any needless zeros, e.g. 92.5 or 60.
+func fmtTempo(bpm float64) string {
+	return strconv.FormatFloat(bpm, 'f', -1, 64)
+}
+
+// fmtTempoDelta formats a change in tempo, always with its sign, e.g. +10 or -2.5.
+func fmtTempoDelta(delta float64) string {
+	if delta >= 0 {
+		return "+" + fmtTempo(delta)
+	}
+	return fmtTempo(delta)
+}
+
+// tempoPeriod returns how long a beat is at bpm, exactly, or as exactly as nanoseconds go.
+func tempoPeriod(bpm float64) time.Duration {
+	return time.Duration(math.Round(float64(time.Minute) / bpm))
+}
+
+// Tap counts a tap, for tap tempo. Once there are a couple in a row, the tempo is set
+// to match, to a tenth, and returned. Otherwise it returns zero.
+func (c *control) Tap() (float64, error) {
+	now := time.Now()
+	c.mu.Lock()
+	if len(c.taps) > 0 && now.Sub(c.taps[len(c.taps)-1]) > tapTimeout {
+		// Too long since the last: start over.
+		c.taps = c.taps[:0]
+	}
+	c.taps = append(c.taps, now)
+	if len(c.taps) > tapCount {
+		c.taps = c.taps[1:]
+	}
+	taps := len(c.taps)
+	first := c.taps[0]
+	c.mu.Unlock()
+
+	if taps < 2 {
+		return 0, nil
+	}
+	period := now.Sub(first) / time.Duration(taps-1)
+	bpm := roundTempo(float64(time.Minute) / float64(period))
+	return bpm, c.SetTempo(bpm)
+}
+
+// Preset sets the tempo to tempo preset n, counting from one (see tempoPresets).
+func (c *control) Preset(n int) error {
+	if n < 1 || n > len(tempoPresets) {
+		return fmt.Errorf("no preset %d, only 1-%d", n, len(tempoPresets))
+	}
+	return c.SetTempo(tempoPresets[n-1])
+}
+
+// SetMarking sets the tempo to the middle of the named tempo marking (see tempoMarkings).
+func (c *control) SetMarking(name string) error {
+	m, err := findMarking(name)
+	if err != nil {
+		return err
+	}
+	return c.SetTempo(m.Tempo)
+}
+
+// SetStyle sets the tempo, signature, and hit pattern of the named style (see tempoStyles)
+// all in one go.
+func (c *control) SetStyle(name string) error {
+	style, err := findStyle(name)
+	if err != nil {
+		return err
+	}
+	if err := checkTempo(style.Tempo); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	if err := c.ts.FromString(style.Signature); err != nil {
+		// We wrote them. Impossible!
+		panic(err)
+	}
+	c.signature = style.Signature
+	c.setPattern(style.Pattern)
+	err = c.setTempo(style.Tempo) // which notes the session's change, signature and all
+	c.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	c.changed()
+	return nil
+}
+
+// SetSignature changes the time signature (e.g. "3/4"), and resets the hit pattern
+// to hit every beat of the new signature.
+func (c *control) SetSignature(ts string) error {
+	c.mu.Lock()
+	if err := c.ts.FromString(ts); err != nil {
+		c.mu.Unlock()
+		return fmt.Errorf("invalid signature %q", ts)
+	}
+	c.signature = ts
+	c.setPattern(beatString(c.ts.Beats.Load()))
+	c.sessionChange()
+	c.mu.Unlock()
+
+	c.changed()
+	return nil
+}
+
+// SetPattern changes the hit pattern, e.g. "13" to only sound beats one and three,
+// or "1>3" to accent the one (see beatState).
+func (c *control) SetPattern(pattern string) error {
+	for _, r := range pattern {
+		if !unicode.IsDigit(r) && r != ',' && r != ' ' && r != '>' {
+			return fmt.Errorf("invalid pattern %q", pattern)
+		}
+	}
+	c.mu.Lock()
+	c.setPattern(pattern)
+	c.mu.Unlock()
+
+	c.changed()
+	return nil
+}
+
+// setPattern is SetPattern for callers already holding c.mu.
+func (c *control) setPattern(pattern string) {
+	c.pattern = pattern
+	c.beats = patternBeats(pattern)
+	if err := c.g.SetTickFilter(c.tickFilter(pattern)); err != nil {
+		// The only error is if tf is nil. Impossible!
+		panic(err)
+	}
+
+	var accenting bool
+	for _, state := range c.beats {
+		accenting = accenting || state == beatAccent
+	}
+	c.emu.Lock()
+	c.accenting = accenting
+	c.emu.Unlock()
+	// The next beat may be accented now, or not. The sounds are known good, so it can't fail.
+	c.queueBeat(c.next, c.nextCountIn)
+}
+
+// SetSound changes the sound to the named one (see embeds.go).
+func (c *control) SetSound(sound string) error {
+	if _, ok := sounds[sound]; !ok {
+		return fmt.Errorf("unknown sound %q", sound)
+	}
+
+	c.mu.Lock()
+	old := c.sound
+	c.sound = sound
+	if err := c.queueBeat(c.next, c.nextCountIn); err != nil {
+		c.sound = old
+		c.mu.Unlock()
+		return err
+	}
+	c.mu.Unlock()
+
+	c.changed()
+	return nil
+}
+
+// Command runs one textual command, which is how the line-based surfaces (e.g. stdio)
+// share the command set. Commands are:
+//
+//	start, stop, pause, restart, mute, pan
+//	tempo 96, tempo 92.5, tempo +5, tempo -5, tempo +5%, tempo -5%
+//	tap (for tap tempo)
+//	preset 3
+//	sig 7/8 (or signature 7/8)
+//	pattern 1,3 (or 1>,3 to accent the one)
+//	sound Finger Cymbals
+//	marking Allegro
+//	style bossa nova
+//	countin 2 (bars, or countin 1 2 for the last two beats of one)
+//	gap 2 2 (bars on, bars off, or gap 4 4 10% to drop a tenth of the beats too, or gap off)
+//	practice 10 (minutes, or 90s), practice 10 2 (with a 2 minute break), practice bar 32, practice off
+//	status (announces the current state)
+//
+// Blank lines are ignored.
+func (c *control) Command(line string) error {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return nil
+	}
+	cmd, arg := strings.ToLower(fields[0]), strings.Join(fields[1:], " ")
+
+	switch cmd {
+	case "start":
+		return c.Start()
+	case "stop":
+		return c.Stop()
+	case "pause":
+		return c.Pause()
+	case "restart":
+		return c.Restart()
+	case "mute":
+		c.Mute()
+		return nil
+	case "pan":
+		c.Pan()
+		return nil
+	case "tempo":
+		if pct, ok := strings.CutSuffix(arg, "%"); ok {
+			n, err := strconv.ParseFloat(pct, 64)
+			if err != nil || !(strings.HasPrefix(pct, "+") || strings.HasPrefix(pct, "-")) {
+				return fmt.Errorf("invalid tempo %q", arg)
+			}
+			return c.NudgeTempoPercent(n)
+		}
+		n, err := strconv.ParseFloat(arg, 64)
+		if err != nil {
+			return fmt.Errorf("invalid tempo %q", arg)
+		}
+		if strings.HasPrefix(arg, "+") || strings.HasPrefix(arg, "-") {
+			return c.NudgeTempo(n)
+		}
+		return c.SetTempo(n)
+	case "tap":
+		_, err := c.Tap()
+		return err
+	case "preset":
+		n, err := strconv.Atoi(arg)
+		if err != nil {
+			return fmt.Errorf("invalid preset %q", arg)
+		}
+		return c.Preset(n)
+	case "marking":
+		return c.SetMarking(arg)
+	case "style":
+		return c.SetStyle(arg)
+	case "countin":
+		bars, beats, err := parseCountIn(arg)
+		if err != nil {
+			return err
+		}
+		return c.SetCountIn(bars, beats)
+	case "gap":
+		play, silent, random, err := parseGap(arg)
+		if err != nil {
+			return err
+		}
+		return c.SetGap(play, silent, random)
+	case "sig", "signature":
+		return c.SetSignature(arg)
+	case "pattern":
+		return c.SetPattern(arg)
+	case "sound":
+		return c.SetSound(arg)
+	case "practice":
+		d, bars, brk, err := parsePractice(arg)
+		if err != nil {
+			return err
+		}
+		return c.SetPractice(d, bars, brk)
+	case "status":
+		c.changed()
+		return nil
+	}
+	return fmt.Errorf("unknown command %q", cmd)
+}
+
+// Subscribe returns a channel that will receive events until Unsubscribe is called with it.
+// Events are dropped, not queued, for subscribers that fall behind.
+func (c *control) Subscribe() chan controlEvent {
+	ch := make(chan controlEvent, 16)
+	c.emu.Lock()
+	c.subs[ch] = struct{}{}
+	c.emu.Unlock()
+	return ch
+}
+
+// Unsubscribe stops and closes a channel returned by Subscribe.
+func (c *control) Unsubscribe(ch chan controlEvent) {
+	c.emu.Lock()
+	defer c.emu.Unlock()
+	if _, ok := c.subs[ch]; ok {
+		delete(c.subs, ch)
+		close(ch)
+	}
+}
+
+// tick must be called from the gnome's tick function, to keep count of measures and tell subscribers.
+func (c *control) tick(beat int) {
+	now := time.Now()
+	c.emu.Lock()
+	defer c.emu.Unlock()
+	c.beatAt = c.beatDue(now)
+	countIn := c.countInTick()
+	if c.voice != nil || c.accenting || countIn == 1 {
+		// The next beat says its number, or may be accented, or the count-in's over. The queuer queues it, not
+		// us, as whoever holds mu may be waiting on the gnome, and we may be the gnome.
+		c.pending = &pendingBeat{beat: beat%int(c.ts.Beats.Load()) + 1, countIn: c.countLeft > 0, starts: c.starts}
+		if !c.queuing {
+			c.queuing = true
+			go c.queuer()
+		}
+	}
+	if beat == 1 {
+		if countIn == 0 {
+			c.measure++
+		}
+		c.downbeat = now
+	}
+	c.gapQuiet = countIn == 0 && c.gapSilentBar(c.measure)
+	if countIn == 0 && beat == int(c.ts.Beats.Load()) {
+		c.practiceBar(c.measure)
+	}
+	c.broadcast(controlEvent{Type: "beat", Time: now, Beat: beat, Measure: c.measure, CountIn: countIn, Silent: c.gapQuiet, Unheard: c.unheard})
+}
+
+// pendingBeat is a beat tick wants queued, for the queuer.
+type pendingBeat struct {
+	beat    int
+	countIn bool
+	starts  int // c.starts, as it was
+}
+
+// queuer queues the beat tick left pending, if it's still for this (re)start, until tick
+// leaves no more. There's only ever one, so a late one can't queue an old beat over a newer.
+func (c *control) queuer() {
+	for {
+		c.mu.Lock()
+		c.emu.Lock()
+		p := c.pending
+		c.pending = nil
+		c.queuing = p != nil
+		stale := p != nil && p.starts != c.starts
+		c.emu.Unlock()
+		if p != nil && !stale {
+			c.queueBeat(p.beat, p.countIn)
+		}
+		c.mu.Unlock()
+
+		if p == nil {
+			return
+		}
+	}
+}
+
+// Downbeat returns when the last measure started, and which measure that was.
+// Measure is zero if there hasn't been one since the last (re)start.
+func (c *control) Downbeat() (time.Time, int) {
+	c.emu.Lock()
+	defer c.emu.Unlock()
+	return c.downbeat, c.measure
+}
+
+// LastBeat returns when the last beat was due (see beatDue), or zero if the gnome isn't
+// ticking, being stopped or paused.
+func (c *control) LastBeat() time.Time {
+	c.mu.Lock()
+	ticking := c.running && !c.g.IsPaused()
+	c.mu.Unlock()
+	if !ticking {
+		return time.Time{}
+	}
+
+	c.emu.Lock()
+	defer c.emu.Unlock()
+	return c.beatAt
+}
+
+// beatDue returns when the beat ticking now was due. The gnome can only tell us late, never
+// early, so the schedule is the earliest the beats have come, counting back whole periods.
+// It starts over when they stray from it, e.g. after a pause, or at a new tempo. Call it
+// holding emu.
+func (c *control) beatDue(now time.Time) time.Time {
+	period := c.Period()
+	c.beatsFrom++
+	due := c.beatFrom.Add(time.Duration(c.beatsFrom) * period)
+	if c.beatFrom.IsZero() || period != c.beatEvery || now.Before(due) || now.Sub(due) > period/4 {
+		c.beatFrom, c.beatsFrom, c.beatEvery = now, 0, period
+		return now
+	}
+	return due
+}
+
+// resetMeasure starts the measure count over, e.g. after a (re)start.
+func (c *control) resetMeasure() {
+	c.emu.Lock()
+	c.measure = 0
+	c.downbeat = time.Time{}
+	c.beatAt, c.beatFrom = time.Time{}, time.Time{}
+	c.emu.Unlock()
+}
+
+// changed tells onChange and the subscribers about the new state. Must not be called holding c.mu.
+func (c *control) changed() {
+	c.mu.Lock()
+	s := c.status()
+	onChange := c.onChange
+	c.mu.Unlock()
+
+	c.emu.Lock()
+	c.broadcast(controlEvent{Type: "state", Time: time.Now(), Status: &s})
+	c.emu.Unlock()
+
+	if onChange != nil {
+		onChange(s)
+	}
+}
+
+// broadcast sends e to every subscriber that has room for it. Must be called holding c.emu.
+func (c *control) broadcast(e controlEvent) {
+	for ch := range c.subs {
+		select {
+		case ch <- e:
+		default:
+			// slowpoke
+		}
+	}
+}
+
+// reportError tells the subscribers about err, e.g. a command from some surface that failed.
+func (c *control) reportError(err error) {
+	c.emu.Lock()
+	defer c.emu.Unlock()
+	c.broadcast(controlEvent{Type: "error", Time: time.Now(), Error: err.Error()})
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// newTestControl returns a control for a silent gnome, as the tunables have it.
+func newTestControl() *control {
+	var c *control
+	c = newControl(newNullGnome(beatsPerMeasure, tempoBPM, func(beat int) { c.tick(beat) }))
+	c.SetPattern(beatString(beatsPerMeasure))
+	return c
+}
+
+func TestCheckTempo(t *testing.T) {
+	tests := []struct {
+		bpm float64
+		ok  bool
+	}{
+		{tempoMin, true},
+		{tempoMax, true},
+		{92.5, true},
+		{tempoMin - 0.1, false},
+		{tempoMax + 0.1, false},
+		{0, false},
+		{-60, false},
+	}
+	for _, tt := range tests {
+		if err := checkTempo(tt.bpm); (err == nil) != tt.ok {
+			t.Errorf("checkTempo(%v) = %v, want ok %v", tt.bpm, err, tt.ok)
+		}
+	}
+}
+
+func TestRoundTempo(t *testing.T) {
+	tests := []struct {
+		bpm, want float64
+	}{
+		{60, 60},
+		{92.5, 92.5},
+		{92.54, 92.5},
+		{92.55, 92.6},
+		{119.96, 120},
+		{0.04, 0},
+	}
+	for _, tt := range tests {
+		if got := roundTempo(tt.bpm); got != tt.want {
+			t.Errorf("roundTempo(%v) = %v, want %v", tt.bpm, got, tt.want)
+		}
+	}
+}
+
+func TestCommand(t *testing.T) {
+	tests := []struct {
+		line  string
+		ok    bool
+		check func(controlStatus) bool
+	}{
+		{"", true, nil},
+		{"tempo 92.5", true, func(s controlStatus) bool { return s.Tempo == 92.5 }},
+		{"TEMPO 100", true, func(s controlStatus) bool { return s.Tempo == 100 }},
+		{"tempo +10", true, func(s controlStatus) bool { return s.Tempo == 70 }},
+		{"tempo -2.5", true, func(s controlStatus) bool { return s.Tempo == 57.5 }},
+		{"tempo +10%", true, func(s controlStatus) bool { return s.Tempo == 66 }},
+		{"tempo 10%", false, nil},
+		{"tempo fast", false, nil},
+		{"tempo 1000", false, nil},
+		{"preset 1", true, func(s controlStatus) bool { return s.Tempo == tempoPresets[0] }},
+		{"preset one", false, nil},
+		{"sig 3/4", true, func(s controlStatus) bool { return s.Signature == "3/4" && s.Pattern == "123" }},
+		{"pattern 1>3", true, func(s controlStatus) bool { return s.Pattern == "1>3" }},
+		{"countin 1 2", true, func(s controlStatus) bool { return s.CountIn == 1 && s.CountOnly == 2 }},
+		{"countin 1 9", false, nil},
+		{"countin 1 2 3", false, nil},
+		{"gap 2 2 10%", true, func(s controlStatus) bool { return s.GapPlay == 2 && s.GapSilent == 2 && s.GapRandom == 10 }},
+		{"gap 2", false, nil},
+		{"practice bar 32", true, func(s controlStatus) bool { return s.PracticeBars == 32 }},
+		{"practice bar", false, nil},
+		{"marking Allegro", true, func(s controlStatus) bool { return s.Tempo == 138 }},
+		{"marking allegro", true, func(s controlStatus) bool { return s.Tempo == 138 }},
+		{"marking Fast", false, nil},
+		{"style waltz", true, func(s controlStatus) bool { return s.Tempo == 90 && s.Signature == "3/4" && s.Pattern == "1>23" }},
+		{"style Viennese waltz", true, func(s controlStatus) bool { return s.Tempo == 180 }},
+		{"style polka dots", false, nil},
+		{"sound Nope", false, nil},
+		{"dance", false, nil},
+	}
+	for _, tt := range tests {
+		c := newControl(newNullGnome(4, 60, func(int) {}))
+		err := c.Command(tt.line)
+		if (err == nil) != tt.ok {
+			t.Errorf("Command(%q) = %v, want ok %v", tt.line, err, tt.ok)
+			continue
+		}
+		if tt.check != nil && !tt.check(c.Status()) {
+			t.Errorf("Command(%q) left the status %+v", tt.line, c.Status())
+		}
+	}
+}
+
+func TestBeatDue(t *testing.T) {
+	const period = time.Second // 60 BPM
+	start := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		late []time.Duration // of each beat, after its time on the schedule
+		want time.Duration   // the last beat's due time, after start
+	}{
+		{"on time", []time.Duration{0, 0, 0}, 2 * period},
+		{"late", []time.Duration{0, 30 * time.Millisecond, 20 * time.Millisecond}, 2 * period},
+		{"late first", []time.Duration{40 * time.Millisecond, 0, 10 * time.Millisecond}, 2 * period},
+		{"paused", []time.Duration{0, 0, 700 * time.Millisecond}, 2*period + 700*time.Millisecond},
+	}
+	for _, tt := range tests {
+		c := newControl(newNullGnome(4, 60, func(int) {}))
+		var got time.Time
+		for i, late := range tt.late {
+			got = c.beatDue(start.Add(time.Duration(i)*period + late))
+		}
+		if want := start.Add(tt.want); !got.Equal(want) {
+			t.Errorf("%s: beatDue = %s after start, want %s", tt.name, got.Sub(start), tt.want)
+		}
+	}
+}
+
+func TestTickQueuesInOrder(t *testing.T) {
+	c := newTestControl()
+	c.SetPattern("1>234") // so every beat is queued
+	for i := range 1000 {
+		c.tick(i%4 + 1)
+	}
+
+	for deadline := time.Now().Add(time.Second); ; time.Sleep(time.Millisecond) {
+		c.emu.Lock()
+		queuing := c.queuing
+		c.emu.Unlock()
+		if !queuing {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("still queuing")
+		}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.next != 1 || c.playing != accentSound {
+		t.Errorf("after beat 4, beat %d is queued, with %q", c.next, c.playing)
+	}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/cognusion/go-gnome"
+)
+
+// The count-in is a bar or two of clicks, in its own sound, before the first downbeat,
+// whenever the gnome starts or restarts. The gnome counts the bars from beat one like
+// any other, so for just the last few beats (a "3, 4" pickup), the beats before them in
+// the bar are silent, rather than skipped.
+
+// SetCountIn sets how many bars to count in, and if beats isn't zero, only click the
+// last that many beats of them, which is no more than a bar's worth.
+func (c *control) SetCountIn(bars, beats int) error {
+	c.mu.Lock()
+	if most := int(c.ts.Beats.Load()); bars < 0 || beats < 0 || beats > most {
+		c.mu.Unlock()
+		return fmt.Errorf("count-in must be zero or more bars, and 0-%d beats, not %d bars and %d beats", most, bars, beats)
+	}
+	c.countInBars, c.countInBeats = bars, beats
+	c.mu.Unlock()
+
+	c.changed()
+	return nil
+}
+
+// CountIn returns where the last beat was in the count-in, counting down to one, or zero
+// if it was a real one.
+func (c *control) CountIn() int {
+	c.emu.Lock()
+	defer c.emu.Unlock()
+	return c.counting
+}
+
+// startCountIn gets the count-in ready, if there is one, for the gnome to start. Call it
+// holding mu.
+func (c *control) startCountIn() {
+	bars := c.countInBars
+	if c.countInBeats > 0 {
+		bars = max(bars, 1)
+	}
+	left := bars * int(c.ts.Beats.Load())
+
+	c.emu.Lock()
+	c.countLeft, c.countOnly, c.counting = left, c.countInBeats, 0
+	c.gapBar, c.gapQuiet = 0, false
+	c.starts++
+	c.emu.Unlock()
+
+	c.queueBeat(1, left > 0)
+}
+
+// stopCountIn calls off the count-in, if there was one going. Call it holding mu.
+func (c *control) stopCountIn() {
+	c.emu.Lock()
+	c.countLeft, c.counting = 0, 0
+	c.starts++
+	c.emu.Unlock()
+
+	c.queueBeat(1, false)
+}
+
+// countInTick moves the count-in along a beat, returning where beat was in it, counting down
+// to one, or zero if it's a real one. Call it holding emu.
+func (c *control) countInTick() int {
+	c.counting = c.countLeft
+	if c.countLeft > 0 {
+		c.countLeft--
+	}
+	return c.counting
+}
+
+// tickFilter returns the tick filter for pattern, that clicks the count-in regardless, and
+// leaves the gaps silent (see gap.go).
+func (c *control) tickFilter(pattern string) func(int) bool {
+	on := beatStringToTickFilter(pattern)
+	return func(beat int) bool {
+		// Not mu, as whoever holds it may be waiting on the gnome, and we may be the gnome.
+		c.emu.Lock()
+		defer c.emu.Unlock()
+		var hear bool
+		if c.countLeft == 0 {
+			hear = c.gapHear(beat) && on(beat)
+		} else {
+			// Only the last so many beats of the last bar, if that's how it is.
+			beats := int(c.ts.Beats.Load())
+			hear = c.countOnly == 0 || c.countLeft > beats || beat > beats-c.countOnly
+		}
+		// The gnome ticks it after, so tick knows.
+		c.unheard = !hear
+		return hear
+	}
+}
+
+// replaceSound has the gnome click with the named sound, without changing what the sound
+// is said to be. Call it holding mu.
+func (c *control) replaceSound(sound string) error {
+	data, ok := sounds[sound]
+	if !ok {
+		return fmt.Errorf("unknown sound %q", sound)
+	}
+
+	// Get a buffer and pass it on
+	buff := gnome.RPool.Get()
+	buff.Reset(*data)
+	return c.g.ReplaceStreamerFromBuffer(buff)
+}
+
+// parseCountIn parses a count-in as the countin command takes it: bars, and maybe the
+// last so many beats of them to click, e.g. "2", or "1 2".
+func parseCountIn(s string) (bars, beats int, err error) {
+	fields := strings.Fields(s)
+	if len(fields) < 1 || len(fields) > 2 {
+		return 0, 0, fmt.Errorf("invalid count-in %q, must be bars, and maybe the beats of them to click, e.g. 1 2", s)
+	}
+	if bars, err = strconv.Atoi(fields[0]); err != nil {
+		return 0, 0, fmt.Errorf("invalid count-in %q", s)
+	}
+	if len(fields) == 2 {
+		if beats, err = strconv.Atoi(fields[1]); err != nil {
+			return 0, 0, fmt.Errorf("invalid count-in %q", s)
+		}
+	}
+	return bars, beats, nil
+}
@@ -0,0 +1,30 @@
+package main
+
+import "testing"
+
+func TestParseCountIn(t *testing.T) {
+	tests := []struct {
+		s           string
+		bars, beats int
+		ok          bool
+	}{
+		{"2", 2, 0, true},
+		{"1 2", 1, 2, true},
+		{" 1  2 ", 1, 2, true},
+		{"0", 0, 0, true},
+		{"", 0, 0, false},
+		{"1 2 3", 0, 0, false},
+		{"1 2x", 0, 0, false},
+		{"one", 0, 0, false},
+	}
+	for _, tt := range tests {
+		bars, beats, err := parseCountIn(tt.s)
+		if (err == nil) != tt.ok {
+			t.Errorf("parseCountIn(%q) = %v, want ok %v", tt.s, err, tt.ok)
+			continue
+		}
+		if bars != tt.bars || beats != tt.beats {
+			t.Errorf("parseCountIn(%q) = %d, %d, want %d, %d", tt.s, bars, beats, tt.bars, tt.beats)
+		}
+	}
+}
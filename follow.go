@@ -0,0 +1,235 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// followEvery is how often followers check in with the conductor.
+	followEvery = 500 * time.Millisecond
+	// followSamples is how many recent clock samples followers pick the best from.
+	followSamples = 8
+	// followTolerance is how far off the conductor's downbeats a follower may be,
+	// before it starts over on the next one.
+	followTolerance = 30 * time.Millisecond
+)
+
+// syncReply is what the conductor's /sync answers with (see api.go).
+type syncReply struct {
+	Now      time.Time     `json:"now"`      // the conductor's clock, as it answered
+	Downbeat time.Time     `json:"downbeat"` // when the conductor's last measure started, if it's running
+	Period   time.Duration `json:"period"`   // of one beat
+	Beats    int32         `json:"beats"`    // per measure
+	Status   controlStatus `json:"status"`
+}
+
+// newSyncReply returns a syncReply for c, as of now.
+func newSyncReply(c *control) syncReply {
+	downbeat, _ := c.Downbeat()
+	return syncReply{
+		Now:      time.Now(),
+		Downbeat: downbeat,
+		Period:   c.Period(),
+		Beats:    c.ts.Beats.Load(),
+		Status:   c.Status(),
+	}
+}
+
+// clockSample is one estimate of the conductor's clock, relative to ours.
+type clockSample struct {
+	offset time.Duration // theirs - ours
+	rtt    time.Duration // round trip, the smaller the better the estimate
+}
+
+// follower keeps ctl in lockstep with a conductor: tempo, signature, pattern,
+// whether it's running, and where the downbeats land.
+type follower struct {
+	url     string
+	client  *http.Client
+	samples []clockSample
+	offset  time.Duration // best estimate of the conductor's clock - ours
+	last    syncReply     // the last we heard from the conductor
+
+	lead    time.Duration // how long after ctl.Start our first downbeat lands, as we've learned
+	startAt *time.Timer   // pending lined-up start, if any
+	misses  int           // downbeats in a row that were out of tolerance
+}
+
+// runFollow starts following the conductor at url (its --listen address) in the background.
+func runFollow(url string) {
+	if !strings.Contains(url, "://") {
+		url = "http://" + url
+	}
+	f := &follower{
+		url:    strings.TrimSuffix(url, "/") + "/sync",
+		client: &http.Client{Timeout: followEvery},
+	}
+	go f.run()
+}
+
+// run is the follower's loop. Everything follower happens here, so no locking.
+func (f *follower) run() {
+	events := ctl.Subscribe()
+	poll := time.NewTicker(followEvery)
+	defer poll.Stop()
+
+	for {
+		var fire <-chan time.Time
+		if f.startAt != nil {
+			fire = f.startAt.C
+		}
+
+		select {
+		case <-poll.C:
+			if err := f.poll(); err != nil {
+				ctl.reportError(fmt.Errorf("following: %w", err))
+			}
+		case <-fire:
+			f.startAt = nil
+			f.misses = 0
+			if ctl.Status().Running {
+				ctl.Restart()
+			} else {
+				ctl.Start()
+			}
+		case e := <-events:
+			if e.Type == "beat" && e.Beat == 1 {
+				f.check(e.Time)
+			}
+		}
+	}
+}
+
+// poll checks in with the conductor, updating our idea of its clock, and following its lead.
+func (f *follower) poll() error {
+	t0 := time.Now()
+	resp, err := f.client.Get(f.url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("the conductor answered %s", resp.Status)
+	}
+	var r syncReply
+	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		return err
+	}
+	t1 := time.Now()
+
+	// Assume they answered halfway through the round trip, and trust the quickest trips most.
+	f.samples = append(f.samples, clockSample{
+		offset: r.Now.Sub(t0.Add(t1.Sub(t0) / 2)),
+		rtt:    t1.Sub(t0),
+	})
+	if len(f.samples) > followSamples {
+		f.samples = f.samples[1:]
+	}
+	best := f.samples[0]
+	for _, s := range f.samples[1:] {
+		if s.rtt < best.rtt {
+			best = s
+		}
+	}
+	f.offset = best.offset
+	f.last = r
+
+	// Follow the settings
+	s := ctl.Status()
+	if r.Status.Signature != s.Signature {
+		if err := ctl.SetSignature(r.Status.Signature); err != nil {
+			return err
+		}
+		s = ctl.Status()
+	}
+	if r.Status.Pattern != s.Pattern {
+		if err := ctl.SetPattern(r.Status.Pattern); err != nil {
+			return err
+		}
+	}
+	if r.Status.Tempo != s.Tempo {
+		if err := ctl.SetTempo(r.Status.Tempo); err != nil {
+			return err
+		}
+	}
+
+	// Follow the transport
+	switch {
+	case !r.Status.Running:
+		f.cancel()
+		if s.Running {
+			return ctl.Stop()
+		}
+	case r.Status.Paused:
+		f.cancel()
+		if s.Running && !s.Paused {
+			return ctl.Pause()
+		}
+	case s.Running && s.Paused:
+		// They're back, so we are too, and check lines the downbeats up again if need be.
+		f.misses = 0
+		return ctl.Pause()
+	case !s.Running && f.startAt == nil:
+		f.lineUp()
+	}
+	return nil
+}
+
+// check sees if our downbeat at when lines up with the conductor's, and if it
+// keeps not doing so, lines us up again, having learned from the miss.
+func (f *follower) check(when time.Time) {
+	measure := f.last.Period * time.Duration(f.last.Beats)
+	if f.last.Downbeat.IsZero() || measure <= 0 || f.startAt != nil {
+		return
+	}
+
+	// How far, on their clock, our downbeat is from one of theirs.
+	off := when.Add(f.offset).Sub(f.last.Downbeat) % measure
+	if off > measure/2 {
+		off -= measure
+	} else if off < -measure/2 {
+		off += measure
+	}
+
+	if off > followTolerance || off < -followTolerance {
+		f.misses++
+	} else {
+		f.misses = 0
+	}
+	if f.misses >= 2 {
+		// Late means we should start that much sooner, and early later.
+		f.lead += off
+		f.lineUp()
+	}
+}
+
+// lineUp schedules a (re)start that puts our first downbeat on the conductor's next one.
+func (f *follower) lineUp() {
+	measure := f.last.Period * time.Duration(f.last.Beats)
+	if f.last.Downbeat.IsZero() || measure <= 0 {
+		// They haven't had a downbeat yet, so wait and see.
+		return
+	}
+
+	// Their last downbeat, on our clock, moved along to one far enough out to make.
+	now := time.Now()
+	next := f.last.Downbeat.Add(-f.offset)
+	for next.Sub(now) < f.lead+followEvery {
+		next = next.Add(measure)
+	}
+
+	f.cancel()
+	f.startAt = time.NewTimer(next.Sub(now) - f.lead)
+}
+
+// cancel cancels any pending lined-up start.
+func (f *follower) cancel() {
+	if f.startAt != nil {
+		f.startAt.Stop()
+		f.startAt = nil
+	}
+}
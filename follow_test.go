@@ -0,0 +1,113 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testConductor is a conductor whose clock runs ahead of ours, answering /sync.
+type testConductor struct {
+	sync.Mutex
+	ahead    time.Duration // of our clock
+	downbeat time.Time     // on its clock
+	period   time.Duration
+	beats    int32
+	status   controlStatus
+	code     int // to answer with
+}
+
+func (c *testConductor) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	c.Lock()
+	defer c.Unlock()
+	if c.code != http.StatusOK {
+		http.Error(w, "nope", c.code)
+		return
+	}
+	json.NewEncoder(w).Encode(syncReply{
+		Now:      time.Now().Add(c.ahead),
+		Downbeat: c.downbeat,
+		Period:   c.period,
+		Beats:    c.beats,
+		Status:   c.status,
+	})
+}
+
+// set changes what the conductor is up to.
+func (c *testConductor) set(running, paused bool, code int) {
+	c.Lock()
+	defer c.Unlock()
+	c.status.Running, c.status.Paused, c.code = running, paused, code
+}
+
+func TestFollow(t *testing.T) {
+	ctl = newTestControl()
+	defer ctl.Stop()
+
+	const ahead = 2 * time.Second
+	conductor := &testConductor{
+		ahead:    ahead,
+		downbeat: time.Now().Add(ahead - 150*time.Millisecond),
+		period:   200 * time.Millisecond, // 300 BPM, so the measures come around quickly
+		beats:    3,
+		status:   controlStatus{Running: true, Tempo: 300, Signature: "3/4", Pattern: "1>23"},
+		code:     http.StatusOK,
+	}
+	s := httptest.NewServer(conductor)
+	defer s.Close()
+	f := &follower{url: s.URL + "/sync", client: &http.Client{Timeout: followEvery}}
+
+	if err := f.poll(); err != nil {
+		t.Fatal(err)
+	}
+	if off := f.offset - ahead; off > 10*time.Millisecond || off < -10*time.Millisecond {
+		t.Errorf("the conductor's clock is %s ahead, not %s", f.offset, ahead)
+	}
+	if st := ctl.Status(); st.Tempo != 300 || st.Signature != "3/4" || st.Pattern != "1>23" {
+		t.Errorf("following left the status %+v", st)
+	}
+
+	// A start is lined up with one of their downbeats, far enough out to make.
+	if f.startAt == nil {
+		t.Fatal("no start lined up")
+	}
+	<-f.startAt.C
+	fired := time.Now()
+	f.startAt = nil
+	measure := conductor.period * time.Duration(conductor.beats)
+	off := fired.Add(ahead).Sub(conductor.downbeat) % measure
+	if off > measure/2 {
+		off -= measure
+	}
+	if off > followTolerance || off < -followTolerance {
+		t.Errorf("the start fired %s off their downbeats", off)
+	}
+	if err := ctl.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The transport follows theirs, pauses and all.
+	for _, tt := range []struct {
+		name                           string
+		running, paused                bool
+		wantRunning, wantPaused, fails bool
+		code                           int
+	}{
+		{"paused", true, true, true, true, false, http.StatusOK},
+		{"still paused", true, true, true, true, false, http.StatusOK},
+		{"resumed", true, false, true, false, false, http.StatusOK},
+		{"unwell", false, false, true, false, true, http.StatusInternalServerError},
+		{"stopped", false, false, false, false, false, http.StatusOK},
+	} {
+		conductor.set(tt.running, tt.paused, tt.code)
+		if err := f.poll(); (err != nil) != tt.fails {
+			t.Errorf("%s: poll = %v", tt.name, err)
+		}
+		if st := ctl.Status(); st.Running != tt.wantRunning || st.Paused != tt.wantPaused {
+			t.Errorf("%s: following left us running %v, paused %v", tt.name, st.Running, st.Paused)
+		}
+	}
+}
@@ -0,0 +1,18 @@
+//go:build wasm
+
+package main
+
+import (
+	"net/url"
+	"strings"
+	"syscall/js"
+)
+
+func init() {
+	// There's no CLI in a browser, so --follow comes from the
+	// page's query string instead, e.g. ?follow=conductor:8080
+	search := js.Global().Get("location").Get("search").String()
+	if q, err := url.ParseQuery(strings.TrimPrefix(search, "?")); err == nil {
+		followURL = q.Get("follow")
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+)
+
+// The gap trainer clicks for so many bars, then goes silent for so many, over and over,
+// and may drop beats at random too, so you keep time yourself. The gnome keeps time all
+// the while, and only the tick filter goes quiet, so the click comes back on the grid.
+// The filter counts the bars it hears for the sound, and tick the ones it sees for the
+// visuals, as the one may come before the other.
+
+// SetGap has the gnome click for play bars, then go silent for silent bars, and drop
+// random percent of the beats it does click. Zero silent bars is no gaps.
+func (c *control) SetGap(play, silent int, random float64) error {
+	if play < 0 || silent < 0 || (silent > 0 && play == 0) {
+		return fmt.Errorf("gaps must be at least a bar on, and zero or more off, not %d and %d", play, silent)
+	}
+	if !(random >= 0 && random <= 100) { // NaN too
+		return fmt.Errorf("random drops must be 0-100%%, not %s%%", strconv.FormatFloat(random, 'f', -1, 64))
+	}
+	c.emu.Lock()
+	c.gapPlay, c.gapSilent, c.gapRandom = play, silent, random
+	c.emu.Unlock()
+
+	c.changed()
+	return nil
+}
+
+// Gap returns true if the last beat was in a silent bar.
+func (c *control) Gap() bool {
+	c.emu.Lock()
+	defer c.emu.Unlock()
+	return c.gapQuiet
+}
+
+// gapSilentBar returns true if bar, counting from one, is one of the silent ones. Call it
+// holding emu.
+func (c *control) gapSilentBar(bar int) bool {
+	if c.gapSilent == 0 || bar < 1 {
+		return false
+	}
+	return (bar-1)%(c.gapPlay+c.gapSilent) >= c.gapPlay
+}
+
+// gapHear counts beat, if it's a downbeat, and returns false if it's in a silent bar, or
+// dropped at random. Only for the tick filter, and not during the count-in. Call it
+// holding emu.
+func (c *control) gapHear(beat int) bool {
+	if beat == 1 {
+		c.gapBar++
+	}
+	if c.gapSilentBar(c.gapBar) {
+		return false
+	}
+	return c.gapRandom == 0 || rand.Float64()*100 >= c.gapRandom
+}
+
+// parseGap parses gaps as the gap command and the TUI take them: bars on, bars off, and
+// optionally the percent to drop at random, e.g. "2 2" or "4 4 10%", or "off".
+func parseGap(s string) (play, silent int, random float64, err error) {
+	fields := strings.Fields(s)
+	if len(fields) == 1 && strings.EqualFold(fields[0], "off") {
+		return 0, 0, 0, nil
+	}
+	if len(fields) < 2 || len(fields) > 3 {
+		return 0, 0, 0, fmt.Errorf("invalid gaps %q, must be bars on, bars off, and maybe percent dropped, e.g. 2 2 10%%", s)
+	}
+	if play, err = strconv.Atoi(fields[0]); err != nil {
+		return 0, 0, 0, fmt.Errorf("invalid gaps %q", s)
+	}
+	if silent, err = strconv.Atoi(fields[1]); err != nil {
+		return 0, 0, 0, fmt.Errorf("invalid gaps %q", s)
+	}
+	if len(fields) == 3 {
+		if random, err = strconv.ParseFloat(strings.TrimSuffix(fields[2], "%"), 64); err != nil {
+			return 0, 0, 0, fmt.Errorf("invalid gaps %q", s)
+		}
+	}
+	return play, silent, random, nil
+}
+
+// fmtGap formats gaps as parseGap takes them, or "off".
+func fmtGap(play, silent int, random float64) string {
+	if silent == 0 && random == 0 {
+		return "off"
+	}
+	s := fmt.Sprintf("%d %d", play, silent)
+	if random > 0 {
+		s += " " + strconv.FormatFloat(random, 'f', -1, 64) + "%"
+	}
+	return s
+}
@@ -0,0 +1,38 @@
+package main
+
+import "testing"
+
+func TestParseGap(t *testing.T) {
+	tests := []struct {
+		s            string
+		play, silent int
+		random       float64
+		ok           bool
+	}{
+		{"2 2", 2, 2, 0, true},
+		{"4 4 10%", 4, 4, 10, true},
+		{"4 4 12.5", 4, 4, 12.5, true},
+		{"off", 0, 0, 0, true},
+		{"OFF", 0, 0, 0, true},
+		{"", 0, 0, 0, false},
+		{"2", 0, 0, 0, false},
+		{"2 2 10% 1", 0, 0, 0, false},
+		{"two 2", 0, 0, 0, false},
+		{"2 2 lots", 0, 0, 0, false},
+	}
+	for _, tt := range tests {
+		play, silent, random, err := parseGap(tt.s)
+		if (err == nil) != tt.ok {
+			t.Errorf("parseGap(%q) = %v, want ok %v", tt.s, err, tt.ok)
+			continue
+		}
+		if play != tt.play || silent != tt.silent || random != tt.random {
+			t.Errorf("parseGap(%q) = %d, %d, %v, want %d, %d, %v", tt.s, play, silent, random, tt.play, tt.silent, tt.random)
+		}
+		if tt.ok {
+			if p, s, r, _ := parseGap(fmtGap(play, silent, random)); (s != 0 || r != 0) && (p != play || s != silent || r != random) {
+				t.Errorf("parseGap(fmtGap(parseGap(%q))) = %d, %d, %v, which doesn't come back the same", tt.s, p, s, r)
+			}
+		}
+	}
+}
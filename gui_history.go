@@ -0,0 +1,48 @@
+package main
+
+import (
+	"slices"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// historyTap shows the practice log, newest first: a summary per week, with its sessions.
+func (g *gui) historyTap() {
+	if historyPath == "" {
+		dialog.ShowInformation("History", "There's no practice log, so no history.", g.win)
+		return
+	}
+	sessions, err := loadHistory(historyPath, time.Time{})
+	if err != nil {
+		dialog.ShowError(err, g.win)
+		return
+	}
+	if len(sessions) == 0 {
+		dialog.ShowInformation("History", "No practice yet. Start the gnome, and it'll be logged when it stops.", g.win)
+		return
+	}
+
+	// The weeks go in the same order as the sessions, so the next so many are the week's.
+	var lines []string
+	for _, week := range practiceWeeks(sessions) {
+		var these []string
+		for _, s := range sessions[:week.Sessions] {
+			these = append(these, "    "+sessionLine(s))
+		}
+		sessions = sessions[week.Sessions:]
+		slices.Reverse(these)
+		lines = append(append([]string{week.String()}, these...), lines...)
+	}
+
+	list := widget.NewList(
+		func() int { return len(lines) },
+		func() fyne.CanvasObject { return widget.NewLabel("") },
+		func(i widget.ListItemID, o fyne.CanvasObject) { o.(*widget.Label).SetText(lines[i]) },
+	)
+	d := dialog.NewCustom("History", "Close", container.NewGridWrap(fyne.NewSize(560, 360), list), g.win)
+	d.Show()
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	"github.com/charmbracelet/bubbles/key"
+)
+
+// guiKey is a key as the TUI names it, e.g. "up", "f1", or "R", so it can match keys.
+type guiKey string
+
+func (k guiKey) String() string {
+	return string(k)
+}
+
+// guiKeyNames are the TUI's names for the keys that aren't characters.
+var guiKeyNames = map[fyne.KeyName]guiKey{
+	fyne.KeySpace:     " ",
+	fyne.KeyUp:        "up",
+	fyne.KeyDown:      "down",
+	fyne.KeyLeft:      "left",
+	fyne.KeyRight:     "right",
+	fyne.KeyReturn:    "enter",
+	fyne.KeyEnter:     "enter",
+	fyne.KeyTab:       "tab",
+	fyne.KeyBackspace: "backspace",
+	fyne.KeyDelete:    "delete",
+	fyne.KeyInsert:    "insert",
+	fyne.KeyHome:      "home",
+	fyne.KeyEnd:       "end",
+	fyne.KeyPageUp:    "pgup",
+	fyne.KeyPageDown:  "pgdown",
+	fyne.KeyF1:        "f1",
+	fyne.KeyF2:        "f2",
+	fyne.KeyF3:        "f3",
+	fyne.KeyF4:        "f4",
+	fyne.KeyF5:        "f5",
+	fyne.KeyF6:        "f6",
+	fyne.KeyF7:        "f7",
+	fyne.KeyF8:        "f8",
+	fyne.KeyF9:        "f9",
+	fyne.KeyF10:       "f10",
+	fyne.KeyF12:       "f12",
+}
+
+// guiBindings are the TUI's keys the GUI does something with, in the order the help shows them.
+func guiBindings() []key.Binding {
+	return []key.Binding{
+		keys.Pause, keys.Up, keys.Down, keys.Tap, keys.Preset, keys.Stop, keys.Restart,
+		keys.Gap, keys.Practice, keys.History, keys.Game, keys.Mute, keys.Pan, keys.Help,
+	}
+}
+
+// typedKey handles the keys nothing else (e.g. an entry) wanted. F1, F11, and Esc are the
+// GUI's own, and characters come to typedRune.
+func (g *gui) typedKey(ev *fyne.KeyEvent) {
+	switch ev.Name {
+	case fyne.KeyF1:
+		g.shortcutsHelp()
+	case fyne.KeyF11:
+		g.togglePresent()
+	case fyne.KeyEscape:
+		if extra.present.on {
+			g.togglePresent()
+		}
+	default:
+		if k, ok := guiKeyNames[ev.Name]; ok {
+			g.typed(k)
+		}
+	}
+}
+
+// typedRune handles the characters nothing else (e.g. an entry) wanted.
+func (g *gui) typedRune(r rune) {
+	if r == ' ' {
+		// typedKey has it
+		return
+	}
+	g.typed(guiKey(r))
+}
+
+// typed does what k does, as keys has it.
+func (g *gui) typed(k guiKey) {
+	switch {
+	case key.Matches(k, keys.Pause):
+		if g.gameTapped() {
+			// Tapping along, rather than pausing
+			return
+		}
+		if ctl.Status().Running {
+			g.pauseTap()
+		} else {
+			g.startTap()
+		}
+	case key.Matches(k, keys.Up):
+		g.upTap()
+	case key.Matches(k, keys.Down):
+		g.downTap()
+	case key.Matches(k, keys.Tap):
+		if _, err := ctl.Tap(); err != nil {
+			dialog.ShowError(err, g.win)
+		}
+	case key.Matches(k, keys.Preset):
+		// By which of its keys it is, the first being preset 1
+		n := slices.Index(keys.Preset.Keys(), k.String()) + 1
+		if err := ctl.Preset(n); err != nil {
+			dialog.ShowError(err, g.win)
+		}
+	case key.Matches(k, keys.Stop):
+		// Not running is no reason to bother anyone.
+		ctl.Stop()
+	case key.Matches(k, keys.Restart):
+		g.restartTap()
+	case key.Matches(k, keys.Gap):
+		g.gapTap()
+	case key.Matches(k, keys.Practice):
+		g.timerTap()
+	case key.Matches(k, keys.History):
+		g.historyTap()
+	case key.Matches(k, keys.Game):
+		g.gameTap()
+	case key.Matches(k, keys.Mute):
+		g.muteAction()
+	case key.Matches(k, keys.Pan):
+		g.panTap()
+	case key.Matches(k, keys.Help):
+		g.shortcutsHelp()
+	}
+}
+
+// shortcutsHelp shows the keyboard shortcuts, and the tempo presets, over the window.
+func (g *gui) shortcutsHelp() {
+	var b strings.Builder
+	for _, k := range guiBindings() {
+		fmt.Fprintf(&b, "**%s** %s\n\n", k.Help().Key, k.Help().Desc)
+	}
+	fmt.Fprintf(&b, "**F11** present (Esc to come back)\n\n**F1** this help\n\n")
+	presets := make([]string, len(tempoPresets))
+	for i, p := range tempoPresets {
+		presets[i] = fmt.Sprintf("%d: %s", i+1, fmtTempo(p))
+	}
+	fmt.Fprintf(&b, "Presets (BPM) %s\n", strings.Join(presets, ", "))
+
+	dialog.ShowCustom("Keyboard Shortcuts", "Close", widget.NewRichTextFromMarkdown(b.String()), g.win)
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"image/color"
+	"math"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// accentColor is for the downbeat, and accented beats, same as the TUI's.
+var accentColor = color.NRGBA{R: 0xFF, G: 0x75, B: 0xB7, A: 0xFF}
+
+// guiLights are the beat lights, and the bouncing gnome, for seeing the beat as well as hearing it.
+type guiLights struct {
+	row    *fyne.Container // a light per beat
+	bounce *widget.Check   // bounce the gnome?
+	lit    int             // counts lightings, so an old one doesn't put out a new one
+	anim   *fyne.Animation // the bounce, if it's bouncing
+	home   fyne.Position   // where the gnome sits when it isn't
+	states []beatState     // as of the last syncLights
+}
+
+// newLights returns the lights, and the bounce toggle, in a row.
+func (g *gui) newLights() fyne.CanvasObject {
+	extra.lights = &guiLights{
+		row:    container.NewGridWrap(fyne.NewSize(18, 18)),
+		bounce: widget.NewCheck("Bounce", nil),
+	}
+	return container.NewHBox(extra.lights.row, extra.lights.bounce)
+}
+
+// syncLights makes a light per beat of pattern, if the beat count changed.
+func (g *gui) syncLights(pattern string) {
+	l := extra.lights
+	l.states = patternStates(pattern, ctl.ts.Beats.Load())
+	if len(l.row.Objects) == len(l.states) {
+		return
+	}
+	l.row.RemoveAll()
+	for range l.states {
+		l.row.Add(canvas.NewCircle(theme.Color(theme.ColorNameInputBackground)))
+	}
+}
+
+// lightBeat lights beat's light, for a moment, and bounces the gnome if it should. Call it with fyne.Do.
+func (g *gui) lightBeat(beat int) {
+	l := extra.lights
+	if beat < 1 || beat > len(l.row.Objects) {
+		return
+	}
+
+	// The downbeat, and accents, stand out.
+	big := beat == 1 || l.states[beat-1] == beatAccent
+	var c color.Color
+	switch {
+	case big:
+		c = accentColor
+	case l.states[beat-1] == beatOff:
+		c = theme.Color(theme.ColorNameDisabled)
+	default:
+		c = theme.Color(theme.ColorNamePrimary)
+	}
+
+	for i, o := range l.row.Objects {
+		light := o.(*canvas.Circle)
+		if i == beat-1 {
+			light.FillColor = c
+		} else {
+			light.FillColor = theme.Color(theme.ColorNameInputBackground)
+		}
+		light.Refresh()
+	}
+
+	// Out again in a bit, unless the next beat beat us to it
+	l.lit++
+	lit := l.lit
+	d := min(150*time.Millisecond, ctl.Period()/2)
+	time.AfterFunc(d, func() {
+		fyne.Do(func() {
+			if l.lit == lit {
+				light := l.row.Objects[beat-1].(*canvas.Circle)
+				light.FillColor = theme.Color(theme.ColorNameInputBackground)
+				light.Refresh()
+			}
+		})
+	})
+
+	if l.bounce.Checked {
+		g.bounceGnome(big, d*2)
+	}
+}
+
+// bounceGnome hops the gnome portrait up and back down over d, higher if big.
+func (g *gui) bounceGnome(big bool, d time.Duration) {
+	l := extra.lights
+	if l.anim != nil {
+		l.anim.Stop()
+	} else {
+		l.home = g.gnomes.Position()
+	}
+
+	height := float32(10)
+	if big {
+		height = 24
+	}
+	l.anim = fyne.NewAnimation(d, func(t float32) {
+		g.gnomes.Move(l.home.SubtractXY(0, height*float32(math.Sin(math.Pi*float64(t)))))
+		if t >= 1 {
+			l.anim = nil
+		}
+	})
+	l.anim.Curve = fyne.AnimationLinear
+	l.anim.Start()
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// newPractice returns the practice timer's countdown, which is empty without one.
+func (g *gui) newPractice() fyne.CanvasObject {
+	extra.practice = widget.NewLabel("")
+	return extra.practice
+}
+
+// practiceClock keeps the countdown counting, between beats, and during the breaks, when
+// nothing else changes. It never returns.
+func (g *gui) practiceClock() {
+	for range time.Tick(time.Second) {
+		s := ctl.Status()
+		fyne.Do(func() { g.syncPractice(s) })
+	}
+}
+
+// practiceDone tells the desktop the practice timer went off.
+func practiceDone(msg string) {
+	fyne.CurrentApp().SendNotification(fyne.NewNotification("MetroGnome", msg))
+}
+
+// syncPractice makes the countdown reflect s.
+func (g *gui) syncPractice(s controlStatus) {
+	var text string
+	switch {
+	case s.BreakLeft > 0:
+		text = "Break, back in " + fmtClock(time.Duration(s.BreakLeft)*time.Second)
+	case s.PracticeFor > 0 && s.Started:
+		text = fmtClock(time.Duration(s.PracticeLeft)*time.Second) + " left to practice"
+	case s.PracticeBars > 0:
+		text = fmt.Sprintf("Practicing to bar %d", s.PracticeBars)
+	}
+	if extra.practice.Text != text {
+		extra.practice.SetText(text)
+	}
+}
+
+// gapTap shows the gap trainer's settings (see gap.go).
+func (g *gui) gapTap() {
+	s := ctl.Status()
+	play, silent, random := widget.NewEntry(), widget.NewEntry(), widget.NewEntry()
+	play.SetText(strconv.Itoa(max(s.GapPlay, 1)))
+	silent.SetText(strconv.Itoa(s.GapSilent))
+	random.SetText(strconv.FormatFloat(s.GapRandom, 'f', -1, 64))
+
+	items := []*widget.FormItem{
+		{Text: "Click for", Widget: play, HintText: "bars, then"},
+		{Text: "Go silent for", Widget: silent, HintText: "bars, or 0 for no gaps"},
+		{Text: "Drop at random", Widget: random, HintText: "percent of the beats"},
+	}
+	dialog.ShowForm("Gap Trainer", "Set", "Cancel", items, func(ok bool) {
+		if !ok {
+			return
+		}
+		p, err1 := strconv.Atoi(play.Text)
+		q, err2 := strconv.Atoi(silent.Text)
+		r, err3 := strconv.ParseFloat(random.Text, 64)
+		if err1 != nil || err2 != nil || err3 != nil {
+			dialog.ShowError(fmt.Errorf("Invalid gaps: must be whole bars, and a percent"), g.win)
+			return
+		}
+		if err := ctl.SetGap(p, q, r); err != nil {
+			dialog.ShowError(err, g.win)
+		}
+	}, g.win)
+}
+
+// timerTap shows the practice timer's settings (see practice.go).
+func (g *gui) timerTap() {
+	s := ctl.Status()
+	minutes, bars, rest := widget.NewEntry(), widget.NewEntry(), widget.NewEntry()
+	minutes.SetText(fmtMinutes(time.Duration(s.PracticeFor) * time.Second))
+	bars.SetText(strconv.Itoa(s.PracticeBars))
+	rest.SetText(fmtMinutes(time.Duration(s.PracticeBreak) * time.Second))
+
+	items := []*widget.FormItem{
+		{Text: "Practice for", Widget: minutes, HintText: "minutes, or 0 for no timer"},
+		{Text: "Or up to bar", Widget: bars, HintText: "or 0 for no timer"},
+		{Text: "Then a break of", Widget: rest, HintText: "minutes, and again, or 0 to stop"},
+	}
+	dialog.ShowForm("Practice Timer", "Set", "Cancel", items, func(ok bool) {
+		if !ok {
+			return
+		}
+		d, err1 := parseMinutes(minutes.Text)
+		b, err2 := strconv.Atoi(bars.Text)
+		brk, err3 := parseMinutes(rest.Text)
+		if err1 != nil || err2 != nil || err3 != nil {
+			dialog.ShowError(fmt.Errorf("Invalid practice timer: must be minutes, and a whole bar"), g.win)
+			return
+		}
+		if err := ctl.SetPractice(d, b, brk); err != nil {
+			dialog.ShowError(err, g.win)
+		}
+	}, g.win)
+}
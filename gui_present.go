@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+)
+
+// guiPresent is the fullscreen presentation mode, for the projector: the beat, tempo and
+// signature, and the gnome, as big as they go, and no buttons. The keyboard still works.
+type guiPresent struct {
+	on      bool
+	content fyne.CanvasObject // the usual window content, for when we're done
+	beat    *canvas.Text
+	info    *canvas.Text // tempo and signature
+	gnome   *canvas.Image
+	view    fyne.CanvasObject
+}
+
+// newPresent makes the presentation, ready for togglePresent.
+func (g *gui) newPresent() {
+	p := &guiPresent{
+		beat:  canvas.NewText("", theme.Color(theme.ColorNameForeground)),
+		info:  canvas.NewText("", theme.Color(theme.ColorNameForeground)),
+		gnome: &canvas.Image{FillMode: canvas.ImageFillContain},
+	}
+	p.beat.Alignment = fyne.TextAlignCenter
+	p.beat.TextStyle.Bold = true
+	p.info.Alignment = fyne.TextAlignCenter
+
+	p.view = container.NewBorder(p.info, nil, nil, nil,
+		container.NewGridWithColumns(2, container.NewCenter(p.beat), p.gnome))
+	extra.present = p
+}
+
+// togglePresent goes into, or out of, the presentation.
+func (g *gui) togglePresent() {
+	p := extra.present
+	p.on = !p.on
+	if p.on {
+		p.content = g.win.Content()
+		p.gnome.Resource = g.gnomes.Resource
+		g.win.SetContent(p.view)
+		g.win.SetFullScreen(true)
+		g.syncPresent(ctl.Status())
+	} else {
+		g.win.SetFullScreen(false)
+		g.win.SetContent(p.content)
+	}
+}
+
+// syncPresent makes the presentation reflect s, if we're presenting.
+func (g *gui) syncPresent(s controlStatus) {
+	p := extra.present
+	if p == nil || !p.on {
+		return
+	}
+	p.info.Text = fmt.Sprintf("%s BPM in %s", fmtTempo(s.Tempo), s.Signature)
+	p.info.TextSize = g.win.Canvas().Size().Height / 12
+	p.info.Refresh()
+}
+
+// presentBeat shows beat in the presentation, if we're presenting. Call it with fyne.Do.
+func (g *gui) presentBeat(beat int) {
+	p := extra.present
+	if p == nil || !p.on {
+		return
+	}
+
+	p.beat.Text = strconv.Itoa(beat)
+	p.beat.TextSize = g.win.Canvas().Size().Height / 2
+	p.beat.Color = theme.Color(theme.ColorNameForeground)
+	if states := extra.lights.states; beat == 1 || (beat <= len(states) && states[beat-1] == beatAccent) {
+		p.beat.Color = accentColor
+	}
+	p.beat.Refresh()
+
+	// The screen may well have changed size since, e.g. going fullscreen.
+	if size := g.win.Canvas().Size().Height / 12; p.info.TextSize != size {
+		p.info.TextSize = size
+		p.info.Refresh()
+	}
+}
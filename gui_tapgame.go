@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// guiGame is the tap-along game (see tapgame.go), as the GUI plays it.
+type guiGame struct {
+	round    *tapRound // the round going, if any
+	feedback *widget.Label
+	dialog   dialog.Dialog
+}
+
+// gameTap starts a round of the tap-along game: a big button to tap along with the gnome
+// (or space), saying how close each tap is, and the round summed up at the end.
+func (g *gui) gameTap() {
+	gg := &extra.game
+	gg.round = &tapRound{taps: tapRoundTaps}
+	gg.feedback = widget.NewLabelWithStyle(fmt.Sprintf("Tap along with the gnome, %d times", tapRoundTaps), fyne.TextAlignCenter, fyne.TextStyle{})
+
+	tap := widget.NewButton("Tap", func() { g.gameTapped() })
+	tap.Importance = widget.HighImportance
+	content := container.NewVBox(
+		gg.feedback,
+		container.NewGridWrap(fyne.NewSize(320, 160), tap),
+	)
+	gg.dialog = dialog.NewCustom("Tap Along", "Stop", content, g.win)
+	gg.dialog.SetOnClosed(func() {
+		if gg.round == nil {
+			return
+		}
+		gg.round.stop()
+		g.gameOver()
+	})
+	gg.dialog.Show()
+}
+
+// gameTapped scores a tap, now, returning false if there's no round going.
+func (g *gui) gameTapped() bool {
+	gg := &extra.game
+	if gg.round == nil || gg.round.done() {
+		return false
+	}
+	off, ok := gg.round.tap(time.Now(), ctl.LastBeat(), ctl.Period())
+	if !ok {
+		gg.feedback.SetText("Wait for the beat")
+		return true
+	}
+	gg.feedback.SetText(fmt.Sprintf("Tap %d of %d: %s, streak %d", len(gg.round.offsets), gg.round.taps, tapFeedback(off), gg.round.streak))
+	if gg.round.done() {
+		gg.dialog.Hide() // on to gameOver
+	}
+	return true
+}
+
+// gameOver shows the round summed up, if there were any taps, and ends it.
+func (g *gui) gameOver() {
+	gg := &extra.game
+	r := gg.round
+	gg.round = nil
+	if len(r.offsets) == 0 {
+		return
+	}
+
+	summary := widget.NewLabelWithStyle(strings.Join(r.summary(), "\n"), fyne.TextAlignLeading, fyne.TextStyle{Monospace: true})
+	dialog.ShowCustom("Tap Along", "Close", summary, g.win)
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// guiTempo is the tempo, to type in exactly, slide around, or nudge by a little or a lot.
+// Faster and Slower (and the arrow keys) still go by tempoDelta.
+type guiTempo struct {
+	entry   *widget.Entry
+	slider  *widget.Slider
+	marking *widget.Select // the Italian tempo markings
+}
+
+// newTempo returns the tempo entry, the fine and percentage nudges, the slider, and the
+// pickers for tempo markings and styles (see tempos.go).
+func (g *gui) newTempo() fyne.CanvasObject {
+	t := &guiTempo{
+		entry:  widget.NewEntry(),
+		slider: widget.NewSlider(tempoMin, tempoMax),
+	}
+	t.slider.Step = 0.1
+	extra.tempo = t
+
+	t.entry.OnSubmitted = func(text string) {
+		bpm, err := strconv.ParseFloat(text, 64)
+		if err == nil {
+			err = ctl.SetTempo(bpm)
+		}
+		if err != nil {
+			dialog.ShowError(fmt.Errorf("Invalid tempo: must be %s-%s", fmtTempo(tempoMin), fmtTempo(tempoMax)), g.win)
+			g.syncTempo(ctl.Status().Tempo)
+		}
+	}
+
+	// Only when they let go, lest we Change the gnome a hundred times on the way.
+	t.slider.OnChangeEnded = func(v float64) {
+		if bpm := roundTempo(v); bpm != ctl.Status().Tempo {
+			ctl.SetTempo(bpm) // the slider can't go out of bounds
+		}
+	}
+
+	markings := make([]string, len(tempoMarkings))
+	for i, m := range tempoMarkings {
+		markings[i] = m.String()
+	}
+	t.marking = widget.NewSelect(markings, func(string) {
+		if err := ctl.SetMarking(tempoMarkings[t.marking.SelectedIndex()].Name); err != nil {
+			// e.g. out of bounds
+			dialog.ShowError(err, g.win)
+			g.syncTempo(ctl.Status().Tempo)
+		}
+	})
+
+	nudge := func(label string, f func() error) *widget.Button {
+		// At the bounds is the only error, which the slider shows.
+		return widget.NewButton(label, func() { f() })
+	}
+	return container.NewVBox(
+		container.NewHBox(
+			container.NewGridWrap(fyne.NewSize(64, t.entry.MinSize().Height), t.entry),
+			widget.NewLabel("BPM"),
+			nudge("-5%", func() error { return ctl.NudgeTempoPercent(-5) }),
+			nudge("-1", func() error { return ctl.NudgeTempo(-1) }),
+			nudge("+1", func() error { return ctl.NudgeTempo(1) }),
+			nudge("+5%", func() error { return ctl.NudgeTempoPercent(5) }),
+		),
+		t.slider,
+		container.NewHBox(t.marking, widget.NewButton("Styles...", g.stylesTap)),
+	)
+}
+
+// stylesTap shows the style library, to search, and pick one from.
+func (g *gui) stylesTap() {
+	found := searchStyles("")
+	list := widget.NewList(
+		func() int { return len(found) },
+		func() fyne.CanvasObject { return widget.NewLabel("") },
+		func(i widget.ListItemID, o fyne.CanvasObject) { o.(*widget.Label).SetText(found[i].String()) },
+	)
+	search := widget.NewEntry()
+	search.SetPlaceHolder("Search, e.g. waltz")
+	search.OnChanged = func(query string) {
+		found = searchStyles(query)
+		list.UnselectAll()
+		list.Refresh()
+	}
+
+	d := dialog.NewCustom("Styles", "Close", container.NewBorder(search, nil, nil, nil, list), g.win)
+	list.OnSelected = func(i widget.ListItemID) {
+		if err := ctl.SetStyle(found[i].Name); err != nil {
+			dialog.ShowError(err, g.win)
+			return
+		}
+		d.Hide()
+	}
+	d.Resize(fyne.NewSize(360, 420))
+	d.Show()
+}
+
+// syncTempo makes the entry and slider show bpm.
+func (g *gui) syncTempo(bpm float64) {
+	t := extra.tempo
+	if text := fmtTempo(bpm); t.entry.Text != text {
+		t.entry.SetText(text)
+	}
+	t.slider.SetValue(bpm)
+
+	// Straight in, as SetSelected would set the tempo.
+	for _, m := range tempoMarkings {
+		if m.Name == markingFor(bpm) && t.marking.Selected != m.String() {
+			t.marking.Selected = m.String()
+			t.marking.Refresh()
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// runHeadless runs the gnome with neither TUI nor GUI, leaving it to the control surfaces.
+// With --control=stdio it returns when stdin does, otherwise when interrupted.
+func runHeadless() {
+	mg = newGnome(func(beat int) { ctl.tick(beat) })
+	defer mg.Close() // cleanups!
+	ctl = newControl(mg)
+
+	// The hit pattern isn't set until someone sets it
+	ctl.SetPattern(beatString(beatsPerMeasure))
+
+	startControl()
+
+	done := make(chan struct{})
+	if controlMode == "stdio" {
+		go func() {
+			defer close(done)
+			runStdio(os.Stdin, os.Stdout) // until EOF
+		}()
+	}
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	select {
+	case <-done:
+	case <-sig:
+	}
+
+	ctl.Stop() // if it's running, which logs the practice session
+	stopControl()
+}
+
+// runStdio reads commands (see control.Command) from in, one per line, and writes
+// events to out as JSON lines, until in runs dry.
+func runStdio(in io.Reader, out io.Writer) {
+	events := ctl.Subscribe()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		enc := json.NewEncoder(out)
+		for e := range events {
+			enc.Encode(e)
+		}
+	}()
+
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		if err := ctl.Command(scanner.Text()); err != nil {
+			ctl.reportError(err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		ctl.reportError(fmt.Errorf("reading commands: %w", err))
+	}
+
+	ctl.Unsubscribe(events) // closes events, so the encoder finishes up
+	<-done
+}
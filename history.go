@@ -0,0 +1,263 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// The practice log is a session per start to stop, appended to historyPath as a JSON line
+// when it stops, for the history subcommand and the GUI's History to look back over.
+
+// practiceSession is one go on the gnome, from start to stop.
+type practiceSession struct {
+	Start      time.Time      `json:"start"`
+	End        time.Time      `json:"end"`
+	Active     float64        `json:"active"` // seconds playing, not counting pauses
+	Paused     float64        `json:"paused"` // seconds paused
+	Pauses     int            `json:"pauses"`
+	Tempos     []sessionTempo `json:"tempos"`     // as it went
+	Signatures []string       `json:"signatures"` // in the order they were first played
+}
+
+// sessionTempo is a tempo change, so many seconds into a session.
+type sessionTempo struct {
+	At    float64 `json:"at"`
+	Tempo float64 `json:"tempo"`
+}
+
+// TempoRange returns the slowest and fastest tempos of the session.
+func (s practiceSession) TempoRange() (slowest, fastest float64) {
+	for i, t := range s.Tempos {
+		if i == 0 || t.Tempo < slowest {
+			slowest = t.Tempo
+		}
+		if t.Tempo > fastest {
+			fastest = t.Tempo
+		}
+	}
+	return slowest, fastest
+}
+
+// defaultHistoryPath returns where the practice log lives, if --history-path doesn't say.
+func defaultHistoryPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "metrognome", "history.jsonl")
+}
+
+// sessionStart starts a session, unless there's one going. Call it holding mu.
+func (c *control) sessionStart() {
+	if c.history == "" || c.session != nil {
+		return
+	}
+	now := time.Now()
+	c.session = &practiceSession{
+		Start:      now,
+		Tempos:     []sessionTempo{{0, c.tempo}},
+		Signatures: []string{c.signature},
+	}
+	c.sessionSince = now
+}
+
+// sessionPause counts a pause, or the end of one. Call it holding mu.
+func (c *control) sessionPause(paused bool) {
+	if c.session == nil {
+		return
+	}
+	now := time.Now()
+	if paused {
+		c.session.Active += now.Sub(c.sessionSince).Seconds()
+		c.session.Pauses++
+	} else {
+		c.session.Paused += now.Sub(c.sessionSince).Seconds()
+	}
+	c.sessionSince = now
+}
+
+// sessionChange notes the tempo and signature, if they've changed. Call it holding mu.
+func (c *control) sessionChange() {
+	if c.session == nil {
+		return
+	}
+	if c.session.Tempos[len(c.session.Tempos)-1].Tempo != c.tempo {
+		at := roundSeconds(time.Since(c.session.Start).Seconds())
+		c.session.Tempos = append(c.session.Tempos, sessionTempo{at, c.tempo})
+	}
+	if !slices.Contains(c.session.Signatures, c.signature) {
+		c.session.Signatures = append(c.session.Signatures, c.signature)
+	}
+}
+
+// sessionEnd ends the session, if there's one going, and logs it, if it came to anything.
+// Call it holding mu.
+func (c *control) sessionEnd() {
+	if c.session == nil {
+		return
+	}
+	s := c.session
+	c.session = nil
+	s.End = time.Now()
+	if c.g.IsPaused() {
+		s.Paused += s.End.Sub(c.sessionSince).Seconds()
+	} else {
+		s.Active += s.End.Sub(c.sessionSince).Seconds()
+	}
+	if s.Active < 1 {
+		// Start, and straight back to stop.
+		return
+	}
+	s.Active, s.Paused = roundSeconds(s.Active), roundSeconds(s.Paused)
+	if err := appendHistory(c.history, *s); err != nil {
+		c.reportError(fmt.Errorf("logging practice: %w", err))
+	}
+}
+
+// roundSeconds rounds seconds to the tenth, which is plenty for a log.
+func roundSeconds(seconds float64) float64 {
+	return math.Round(seconds*10) / 10
+}
+
+// appendHistory appends s to the practice log at path.
+func appendHistory(path string, s practiceSession) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if err := json.NewEncoder(f).Encode(s); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// loadHistory returns the sessions in the practice log at path, oldest first, skipping
+// any that started before since. No log is no sessions.
+func loadHistory(path string, since time.Time) ([]practiceSession, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	// Not a bufio.Scanner, as a long session (all those tempos) can outgrow its lines.
+	var sessions []practiceSession
+	r := bufio.NewReader(f)
+	for line := 1; ; line++ {
+		b, err := r.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return nil, fmt.Errorf("%s, line %d: %w", path, line, err)
+		}
+		if len(bytes.TrimSpace(b)) > 0 {
+			var s practiceSession
+			if err := json.Unmarshal(b, &s); err != nil {
+				return nil, fmt.Errorf("%s, line %d: %w", path, line, err)
+			}
+			if !s.Start.Before(since) {
+				sessions = append(sessions, s)
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+	}
+	slices.SortFunc(sessions, func(a, b practiceSession) int { return a.Start.Compare(b.Start) })
+	return sessions, nil
+}
+
+// practiceWeek is a week of sessions, Monday to Sunday.
+type practiceWeek struct {
+	Monday   time.Time
+	Sessions int
+	Active   float64 // seconds
+	Slowest  float64
+	Fastest  float64
+	Usual    float64 // the tempo played longest
+}
+
+// String is the week, summed up, e.g. "Week of Mon Oct 12: 5 sessions, 52m, 72-96 bpm, mostly 88".
+func (w practiceWeek) String() string {
+	return fmt.Sprintf("Week of %s: %d sessions, %s, %s-%s bpm, mostly %s", w.Monday.Format("Mon Jan 2 2006"), w.Sessions,
+		fmtActive(w.Active), fmtTempo(w.Slowest), fmtTempo(w.Fastest), fmtTempo(w.Usual))
+}
+
+// practiceWeeks sums sessions up by the week, oldest first.
+func practiceWeeks(sessions []practiceSession) []practiceWeek {
+	var (
+		weeks  []practiceWeek
+		played map[float64]float64 // seconds, by tempo
+	)
+	for _, s := range sessions {
+		start := s.Start.Local()
+		day := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, start.Location())
+		monday := day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+		if len(weeks) == 0 || !weeks[len(weeks)-1].Monday.Equal(monday) {
+			weeks = append(weeks, practiceWeek{Monday: monday})
+			played = make(map[float64]float64)
+		}
+		w := &weeks[len(weeks)-1]
+		slowest, fastest := s.TempoRange()
+		if w.Sessions == 0 || slowest < w.Slowest {
+			w.Slowest = slowest
+		}
+		w.Fastest = max(w.Fastest, fastest)
+		w.Sessions++
+		w.Active += s.Active
+
+		// Roughly: the wall clock between changes, pauses and all.
+		for i, t := range s.Tempos {
+			until := s.End.Sub(s.Start).Seconds()
+			if i+1 < len(s.Tempos) {
+				until = s.Tempos[i+1].At
+			}
+			played[t.Tempo] += until - t.At
+			if played[t.Tempo] > played[w.Usual] {
+				w.Usual = t.Tempo
+			}
+		}
+	}
+	return weeks
+}
+
+// sessionLine sums up s on a line, e.g. "Mon Oct 12 16:02  10m  80-88 bpm  4/4, 3/4  2 pauses".
+func sessionLine(s practiceSession) string {
+	slowest, fastest := s.TempoRange()
+	tempos := fmtTempo(slowest)
+	if fastest != slowest {
+		tempos += "-" + fmtTempo(fastest)
+	}
+	line := fmt.Sprintf("%s  %5s  %s bpm  %s", s.Start.Local().Format("Mon Jan _2 15:04"), fmtActive(s.Active), tempos, strings.Join(s.Signatures, ", "))
+	switch s.Pauses {
+	case 0:
+	case 1:
+		line += "  1 pause"
+	default:
+		line += fmt.Sprintf("  %d pauses", s.Pauses)
+	}
+	return line
+}
+
+// fmtActive formats seconds of practice to the minute, or the second if it's under one,
+// e.g. 1h5m or 42s.
+func fmtActive(seconds float64) string {
+	d := time.Duration(seconds * float64(time.Second))
+	if d < time.Minute {
+		return d.Round(time.Second).String()
+	}
+	return strings.TrimSuffix(d.Round(time.Minute).String(), "0s")
+}
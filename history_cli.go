@@ -0,0 +1,116 @@
+//go:build !wasm
+
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+// runHistory is the `metrognome history` subcommand: it sums up the practice log at path,
+// week by week, or exports it as CSV or JSON. It returns the exit code.
+func runHistory(path string, args []string) int {
+	fs := pflag.NewFlagSet("history", pflag.ContinueOnError)
+	days := fs.Int("days", 0, "Only the last this many days (default all of it)")
+	format := fs.String("format", "text", "How to print it: text (a summary per week, and the sessions), csv, or json")
+	fs.SortFlags = false
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, pflag.ErrHelp) {
+			return 0
+		}
+		return 1
+	}
+	if path == "" {
+		fmt.Printf("No practice log path, so no history\n")
+		return 1
+	}
+
+	var since time.Time
+	if *days > 0 {
+		since = time.Now().AddDate(0, 0, -*days)
+	}
+	sessions, err := loadHistory(path, since)
+	if err != nil {
+		fmt.Printf("Could not load the practice log: %s\n", err)
+		return 1
+	}
+
+	switch *format {
+	case "text":
+		printHistory(os.Stdout, sessions)
+	case "csv":
+		err = historyCSV(os.Stdout, sessions)
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if sessions == nil {
+			sessions = []practiceSession{} // [], not null
+		}
+		err = enc.Encode(sessions)
+	default:
+		fmt.Printf("Requested format '%s' is not valid. Must be one of: text, csv, json\n", *format)
+		return 1
+	}
+	if err != nil {
+		fmt.Printf("Could not print the history: %s\n", err)
+		return 1
+	}
+	return 0
+}
+
+// printHistory prints sessions to w, a summary per week with its sessions under it.
+func printHistory(w io.Writer, sessions []practiceSession) {
+	if len(sessions) == 0 {
+		fmt.Fprintf(w, "No practice yet. Start the gnome, and it'll be logged when it stops.\n")
+		return
+	}
+
+	var total float64
+	count, weeks := len(sessions), practiceWeeks(sessions)
+	for _, week := range weeks {
+		// The weeks go in the same order as the sessions, so the next so many are the week's.
+		fmt.Fprintf(w, "%s\n", week)
+		for _, s := range sessions[:week.Sessions] {
+			fmt.Fprintf(w, "  %s\n", sessionLine(s))
+			total += s.Active
+		}
+		sessions = sessions[week.Sessions:]
+	}
+	fmt.Fprintf(w, "%d sessions, %s in all\n", count, fmtActive(total))
+}
+
+// historyCSV writes sessions to w as CSV, a row per session. The tempos are "seconds in:BPM",
+// separated by spaces.
+func historyCSV(w io.Writer, sessions []practiceSession) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"start", "end", "active_seconds", "paused_seconds", "pauses", "slowest_bpm", "fastest_bpm", "tempos", "signatures"})
+	for _, s := range sessions {
+		slowest, fastest := s.TempoRange()
+		tempos := make([]string, len(s.Tempos))
+		for i, t := range s.Tempos {
+			tempos[i] = strconv.Itoa(int(t.At)) + ":" + fmtTempo(t.Tempo)
+		}
+		cw.Write([]string{
+			s.Start.Format(time.RFC3339),
+			s.End.Format(time.RFC3339),
+			strconv.Itoa(int(s.Active)),
+			strconv.Itoa(int(s.Paused)),
+			strconv.Itoa(s.Pauses),
+			fmtTempo(slowest),
+			fmtTempo(fastest),
+			strings.Join(tempos, " "),
+			strings.Join(s.Signatures, " "),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
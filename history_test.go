@@ -0,0 +1,88 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPracticeWeeks(t *testing.T) {
+	// A session of minutes at tempo, on the day of October 2026, at 6pm local
+	session := func(day, minutes int, tempos ...sessionTempo) practiceSession {
+		start := time.Date(2026, 10, day, 18, 0, 0, 0, time.Local)
+		return practiceSession{
+			Start:  start,
+			End:    start.Add(time.Duration(minutes) * time.Minute),
+			Active: float64(minutes * 60),
+			Tempos: tempos,
+		}
+	}
+	monday := func(day int) time.Time { return time.Date(2026, 10, day, 0, 0, 0, 0, time.Local) }
+
+	weeks := practiceWeeks([]practiceSession{
+		session(5, 10, sessionTempo{0, 80}),                         // Monday
+		session(8, 20, sessionTempo{0, 72}, sessionTempo{60, 88}),   // Thursday
+		session(11, 5, sessionTempo{0, 96}),                         // Sunday, still the same week
+		session(12, 10, sessionTempo{0, 100}),                       // Monday, the next
+		session(25, 30, sessionTempo{0, 90}, sessionTempo{600, 92}), // Sunday, a week skipped
+	})
+	want := []practiceWeek{
+		{Monday: monday(5), Sessions: 3, Active: 35 * 60, Slowest: 72, Fastest: 96, Usual: 88},
+		{Monday: monday(12), Sessions: 1, Active: 10 * 60, Slowest: 100, Fastest: 100, Usual: 100},
+		{Monday: monday(19), Sessions: 1, Active: 30 * 60, Slowest: 90, Fastest: 92, Usual: 92},
+	}
+	if len(weeks) != len(want) {
+		t.Fatalf("practiceWeeks = %d weeks, want %d: %v", len(weeks), len(want), weeks)
+	}
+	for i := range want {
+		if !weeks[i].Monday.Equal(want[i].Monday) || weeks[i].Sessions != want[i].Sessions || weeks[i].Active != want[i].Active ||
+			weeks[i].Slowest != want[i].Slowest || weeks[i].Fastest != want[i].Fastest || weeks[i].Usual != want[i].Usual {
+			t.Errorf("practiceWeeks[%d] = %+v, want %+v", i, weeks[i], want[i])
+		}
+	}
+}
+
+func TestLoadHistory(t *testing.T) {
+	start := time.Date(2026, 10, 19, 18, 0, 0, 0, time.UTC)
+	long := practiceSession{Start: start, End: start.Add(time.Hour), Active: 3600}
+	for i := range 5000 {
+		// A tempo change every so often makes for a line well over 64KiB.
+		long.Tempos = append(long.Tempos, sessionTempo{At: float64(i) * 0.7, Tempo: float64(60 + i%100)})
+	}
+	short := practiceSession{Start: start.Add(-24 * time.Hour), End: start.Add(-23 * time.Hour), Active: 3600}
+
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	for _, s := range []practiceSession{long, short} {
+		if err := appendHistory(path, s); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	sessions, err := loadHistory(path, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sessions) != 2 || !sessions[0].Start.Equal(short.Start) || len(sessions[1].Tempos) != len(long.Tempos) {
+		t.Errorf("loaded %d sessions, want the short one, then the long one", len(sessions))
+	}
+
+	if sessions, err := loadHistory(path, start); err != nil || len(sessions) != 1 {
+		t.Errorf("since the long one, loaded %d sessions, %v", len(sessions), err)
+	}
+	if sessions, err := loadHistory(filepath.Join(t.TempDir(), "nope.jsonl"), start); err != nil || sessions != nil {
+		t.Errorf("with no log, loaded %v, %v", sessions, err)
+	}
+
+	// A broken line says where it is.
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString("{\"start\":\n")
+	f.Close()
+	if _, err := loadHistory(path, time.Time{}); err == nil || !strings.Contains(err.Error(), "line 3") {
+		t.Errorf("a broken line 3 loaded with %v", err)
+	}
+}
@@ -0,0 +1,245 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+)
+
+// keyMap is what the keys do, in the TUI, and in the GUI too (see gui_keys.go), which the TUI
+// config can change (see tui_config.go).
+type keyMap struct {
+	Up        key.Binding
+	Down      key.Binding
+	Tempo     key.Binding
+	Tap       key.Binding
+	Preset    key.Binding
+	Pause     key.Binding
+	Stop      key.Binding
+	Restart   key.Binding
+	Signature key.Binding
+	Pattern   key.Binding
+	Sound     key.Binding
+	Marking   key.Binding
+	Style     key.Binding
+	Gap       key.Binding
+	Practice  key.Binding
+	Game      key.Binding
+	History   key.Binding
+	Mute      key.Binding
+	Drift     key.Binding
+	View      key.Binding
+	Pan       key.Binding
+	Bell      key.Binding
+	Flash     key.Binding
+	Help      key.Binding
+	Quit      key.Binding
+}
+
+func (k keyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.Help, k.Quit}
+}
+
+func (k keyMap) FullHelp() [][]key.Binding {
+	// trying to keep each column <= 5 lines
+	return [][]key.Binding{
+		{k.Up, k.Down, k.Tempo, k.Tap, k.Preset},           // first column: the tempo
+		{k.Pause, k.Stop, k.Restart, k.Mute, k.Pan},        // second column: the gnome
+		{k.Signature, k.Pattern, k.Sound, k.Bell, k.Flash}, // third column: the music
+		{k.Marking, k.Style, k.Gap, k.View, k.Drift},       // fourth column: practice, and looks
+		{k.Practice, k.Game, k.History, k.Help, k.Quit},
+	}
+}
+
+var keys = keyMap{
+	Up: key.NewBinding(
+		key.WithKeys("up", "a"),
+		key.WithHelp("↑/a", "tempo up"),
+	),
+	Down: key.NewBinding(
+		key.WithKeys("down", "z"),
+		key.WithHelp("↓/z", "tempo down"),
+	),
+	Tempo: key.NewBinding(
+		key.WithKeys("e"),
+		key.WithHelp("e", "enter tempo"),
+	),
+	Tap: key.NewBinding(
+		key.WithKeys("t"),
+		key.WithHelp("t", "tap tempo"),
+	),
+	Preset: key.NewBinding(
+		key.WithKeys("1", "2", "3", "4", "5", "6", "7", "8", "9"),
+		key.WithHelp("1-9", "tempo presets"),
+	),
+	Pause: key.NewBinding(
+		key.WithKeys(" ", "p"),
+		key.WithHelp("space/p", "Start/Pause"),
+	),
+	Stop: key.NewBinding(
+		key.WithKeys("s"),
+		key.WithHelp("s", "Stop"),
+	),
+	Restart: key.NewBinding(
+		key.WithKeys("R"),
+		key.WithHelp("R", "Restart"),
+	),
+	Signature: key.NewBinding(
+		key.WithKeys("g"),
+		key.WithHelp("g", "set signature"),
+	),
+	Pattern: key.NewBinding(
+		key.WithKeys("h"),
+		key.WithHelp("h", "set hit pattern"),
+	),
+	Sound: key.NewBinding(
+		key.WithKeys("c"),
+		key.WithHelp("c", "pick sound"),
+	),
+	Marking: key.NewBinding(
+		key.WithKeys("i"),
+		key.WithHelp("i", "pick tempo marking"),
+	),
+	Style: key.NewBinding(
+		key.WithKeys("l"),
+		key.WithHelp("l", "style library"),
+	),
+	Gap: key.NewBinding(
+		key.WithKeys("x"),
+		key.WithHelp("x", "gap trainer"),
+	),
+	Practice: key.NewBinding(
+		key.WithKeys("w"),
+		key.WithHelp("w", "practice timer"),
+	),
+	Game: key.NewBinding(
+		key.WithKeys("j"),
+		key.WithHelp("j", "tap-along game"),
+	),
+	History: key.NewBinding(
+		key.WithKeys("o"),
+		key.WithHelp("o", "practice log"),
+	),
+	Pan: key.NewBinding(
+		key.WithKeys("r"),
+		key.WithHelp("r", "Pan/Unpan"),
+	),
+	Mute: key.NewBinding(
+		key.WithKeys("m"),
+		key.WithHelp("m", "Mute/Unmute"),
+	),
+	Drift: key.NewBinding(
+		key.WithKeys("d"),
+		key.WithHelp("d", "Display drift"),
+	),
+	View: key.NewBinding(
+		key.WithKeys("v"),
+		key.WithHelp("v", "change view"),
+	),
+	Bell: key.NewBinding(
+		key.WithKeys("b"),
+		key.WithHelp("b", "Bell on/off"),
+	),
+	Flash: key.NewBinding(
+		key.WithKeys("f"),
+		key.WithHelp("f", "Flash on/off"),
+	),
+	Help: key.NewBinding(
+		key.WithKeys("?"),
+		key.WithHelp("?", "toggle help"),
+	),
+	Quit: key.NewBinding(
+		key.WithKeys("q", "esc", "ctrl+c"),
+		key.WithHelp("q", "quit"),
+	),
+}
+
+// actions returns k's bindings by the names the config file knows them by.
+func (k *keyMap) actions() map[string]*key.Binding {
+	return map[string]*key.Binding{
+		"up":        &k.Up,
+		"down":      &k.Down,
+		"tempo":     &k.Tempo,
+		"tap":       &k.Tap,
+		"preset":    &k.Preset,
+		"pause":     &k.Pause,
+		"stop":      &k.Stop,
+		"restart":   &k.Restart,
+		"signature": &k.Signature,
+		"pattern":   &k.Pattern,
+		"sound":     &k.Sound,
+		"marking":   &k.Marking,
+		"style":     &k.Style,
+		"gap":       &k.Gap,
+		"practice":  &k.Practice,
+		"game":      &k.Game,
+		"history":   &k.History,
+		"mute":      &k.Mute,
+		"drift":     &k.Drift,
+		"view":      &k.View,
+		"pan":       &k.Pan,
+		"bell":      &k.Bell,
+		"flash":     &k.Flash,
+		"help":      &k.Help,
+		"quit":      &k.Quit,
+	}
+}
+
+// apply rebinds the actions in custom, then makes sure no key does two things.
+func (k *keyMap) apply(custom map[string][]string) error {
+	actions := k.actions()
+
+	for name, ks := range custom {
+		b, ok := actions[name]
+		if !ok {
+			return fmt.Errorf("unknown action %q", name)
+		}
+		if len(ks) == 0 {
+			return fmt.Errorf("action %q needs at least one key", name)
+		}
+		*b = key.NewBinding(
+			key.WithKeys(ks...),
+			key.WithHelp(helpKeys(ks), b.Help().Desc),
+		)
+	}
+
+	// Sorted, so the complaint is the same every time.
+	names := make([]string, 0, len(actions))
+	for name := range actions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	taken := make(map[string]string)
+	for _, name := range names {
+		for _, ks := range actions[name].Keys() {
+			if other, ok := taken[ks]; ok {
+				return fmt.Errorf("key %q is bound to both %q and %q", ks, other, name)
+			}
+			taken[ks] = name
+		}
+	}
+	return nil
+}
+
+// helpKeys is how ks are shown in the help, e.g. "↑/a".
+func helpKeys(ks []string) string {
+	shown := make([]string, len(ks))
+	for i, k := range ks {
+		switch k {
+		case "up":
+			shown[i] = "↑"
+		case "down":
+			shown[i] = "↓"
+		case "left":
+			shown[i] = "←"
+		case "right":
+			shown[i] = "→"
+		default:
+			shown[i] = k
+		}
+	}
+	return strings.Join(shown, "/")
+}
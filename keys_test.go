@@ -0,0 +1,51 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/bubbles/key"
+)
+
+func TestKeyMapApply(t *testing.T) {
+	tests := []struct {
+		custom map[string][]string
+		ok     bool
+	}{
+		{nil, true},
+		// tui_config.go's and the README's examples
+		{map[string][]string{"up": {"up", "."}, "down": {"down", "e"}, "tempo": {"enter"}}, true},
+		{map[string][]string{"preset": {"f1", "f2"}}, true},
+		{map[string][]string{"down": {"down", "e"}}, false},
+		{map[string][]string{"pan": {"p"}}, false},
+		{map[string][]string{"dance": {"d"}}, false},
+		{map[string][]string{"up": {}}, false},
+	}
+	for _, tt := range tests {
+		k := keys
+		if err := k.apply(tt.custom); (err == nil) != tt.ok {
+			t.Errorf("apply(%v) = %v, want ok %v", tt.custom, err, tt.ok)
+		}
+	}
+}
+
+func TestKeyMapDefaults(t *testing.T) {
+	// Keys that meant these before there was a key map to change, and still should
+	tests := []struct {
+		key     string
+		binding key.Binding
+	}{
+		{"p", keys.Pause},
+		{" ", keys.Pause},
+		{"r", keys.Pan},
+		{"m", keys.Mute},
+		{"a", keys.Up},
+		{"z", keys.Down},
+		{"d", keys.Drift},
+		{"q", keys.Quit},
+	}
+	for _, tt := range tests {
+		if !key.Matches(guiKey(tt.key), tt.binding) {
+			t.Errorf("%q isn't %q, as it should be", tt.key, tt.binding.Help().Desc)
+		}
+	}
+}
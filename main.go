@@ -2,17 +2,22 @@ package main
 
 import (
 	"fmt"
+	"io"
+	"math"
 	"net/url"
 	"os"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/app"
+	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
 	"github.com/cognusion/go-gnome"
-	"github.com/cognusion/go-recyclable/v2"
 )
 
 const (
@@ -24,20 +29,78 @@ const (
 
 var (
 	// this is our 'gnome
-	mg *gnome.Gnome
+	mg clicker
+
+	// why we're clicking silently, if we are (see newGnome)
+	audioErr error
 
 	// help is here
 	helpURL *url.URL
 
+	// the actions every control surface shares
+	ctl *control
+
 	// TUI globals, because TUI is a conditional compile (!WASM)
-	terminalUI bool
-	runTUIfunc func(*gnome.Gnome)
+	terminalUI        bool
+	tuiConfigPath     string
+	runTUIfunc        func(clicker)
+	loadTUIConfigfunc func(string) error
+
+	// API globals, because the API is a conditional compile (!WASM)
+	listenAddr string
+	runAPIfunc func(string) error
+
+	// socket globals, because the socket is a conditional compile (!WASM)
+	socketOn      bool
+	socketPath    string
+	runSocketfunc func(string) error
+
+	// MPRIS globals, because MPRIS is a conditional compile (Linux && !WASM)
+	mprisOn      bool
+	runMPRISfunc func() error
+
+	// what stopControl closes, added to by each control surface as it starts
+	controlClosers []io.Closer
+
+	// follower globals, set by the CLI (or the page, for WASM)
+	followURL string
+
+	// click globals, set by the CLI (see sanityCheck)
+	clickModes = "audio"
+	clickAudio bool
+	clickBell  bool
+	clickFlash bool
+
+	// headless globals, set by the CLI
+	headless    bool
+	controlMode string
+
+	// widgets we build by hand (see setupActions)
+	extra extraWidgets
 
 	// global tunables
-	tempoBPM        int32  = 60
-	tempoDelta      int32  = 10
-	beatsPerMeasure int32  = 4
-	startSound      string = "Woodblock"
+	tempoBPM        float64 = 60 // BPM are to a tenth (see roundTempo)
+	tempoDelta      float64 = 10
+	tempoMin        float64 = 20
+	tempoMax        float64 = 300
+	beatsPerMeasure int32   = 4
+	startSound      string  = "Woodblock"
+	tempoPresets            = []float64{40, 60, 72, 80, 96, 108, 120, 144, 160}
+	countInBars     int
+	countInBeats    int
+	countInSound    = "Rimshot"
+	accentSound     = "Cowbell"
+	gapPlay         int           // bars to click, then
+	gapSilent       int           // bars not to (see gap.go)
+	gapRandom       float64       // percent of beats to drop at random
+	practiceFor     time.Duration // to play for, then stop (see practice.go)
+	practiceBars    int           // or bars to play
+	practiceBreak   time.Duration // and a break before going again, if any
+	historyPath     string        // the practice log, if we're keeping one (see history.go)
+	voiceName       string        // --voice, if any
+	voiceOnly       bool
+	voiceSub        string
+	countVoice      *voice // voiceName, loaded by sanityCheck
 )
 
 func init() {
@@ -50,14 +113,13 @@ func init() {
 func main() {
 	// to help debug WASM problems, all CLI stuff moved to init()@tui.go
 
-	// Sanity check startSound
-	if _, ok := sounds[startSound]; !ok {
-		fmt.Printf("Requested sound '%s' is not valid. Must be one of: %s\n", startSound, strings.Join(sounds.Keys(), ", "))
-		os.Exit(1)
-	}
+	sanityCheck()
 
 	// Choose our adventure
-	if terminalUI {
+	if headless {
+		// Nobody!!
+		runHeadless() // blocks
+	} else if terminalUI {
 		// TUI!!
 		if runTUIfunc == nil {
 			panic(fmt.Errorf("terminal UI requested but unhinged"))
@@ -71,36 +133,302 @@ func main() {
 
 		loadTheme(a)
 
+		// The keys are the TUI's, as the TUI config has them (see gui_keys.go)
+		if loadTUIConfigfunc != nil {
+			if err := loadTUIConfigfunc(tuiConfigPath); err != nil {
+				fmt.Printf("Could not load the TUI config: %s\n", err)
+				os.Exit(1)
+			}
+		}
+
 		g := newGUI()
 		w := g.makeWindow(a)
 
 		g.setupActions()
 		defer mg.Close() // cleanups!
 
+		startControl()
+		defer stopControl() // the socket file, and all
+		defer ctl.Stop()    // logs the practice session, if there's one going
+
 		w.ShowAndRun()
 	}
 }
 
+// sanityCheck makes sure the tunables make sense, exiting if they don't.
+func sanityCheck() {
+	// Sanity check startSound
+	if _, ok := sounds[startSound]; !ok {
+		fmt.Printf("Requested sound '%s' is not valid. Must be one of: %s\n", startSound, strings.Join(sounds.Keys(), ", "))
+		os.Exit(1)
+	}
+
+	// Tempos are to a tenth, and no finer
+	tempoBPM, tempoDelta = roundTempo(tempoBPM), roundTempo(tempoDelta)
+	for i := range tempoPresets {
+		tempoPresets[i] = roundTempo(tempoPresets[i])
+	}
+
+	// Sanity check the tempo bounds, and everything they bound
+	if !(tempoMin >= 1 && tempoMin <= tempoMax) {
+		fmt.Printf("Requested tempo bounds %s-%s are not valid. Must be at least 1, and min no more than max\n", fmtTempo(tempoMin), fmtTempo(tempoMax))
+		os.Exit(1)
+	}
+	if err := checkTempo(tempoBPM); err != nil {
+		fmt.Printf("Requested tempo is not valid: %s\n", err)
+		os.Exit(1)
+	}
+
+	// Sanity check tempoPresets
+	if len(tempoPresets) > 9 {
+		fmt.Printf("Too many tempo presets (%d). Must be at most 9\n", len(tempoPresets))
+		os.Exit(1)
+	}
+	for _, p := range tempoPresets {
+		if err := checkTempo(p); err != nil {
+			fmt.Printf("Requested tempo preset is not valid: %s\n", err)
+			os.Exit(1)
+		}
+	}
+
+	// Sanity check the count-in
+	if countInBars < 0 || countInBeats < 0 || countInBeats > int(beatsPerMeasure) {
+		fmt.Printf("Requested count-in of %d bars and %d beats is not valid. Must be zero or more bars, and 0-%d beats\n", countInBars, countInBeats, beatsPerMeasure)
+		os.Exit(1)
+	}
+	if _, ok := sounds[countInSound]; !ok {
+		fmt.Printf("Requested count-in sound '%s' is not valid. Must be one of: %s\n", countInSound, strings.Join(sounds.Keys(), ", "))
+		os.Exit(1)
+	}
+	if _, ok := sounds[accentSound]; !ok {
+		fmt.Printf("Requested accent sound '%s' is not valid. Must be one of: %s\n", accentSound, strings.Join(sounds.Keys(), ", "))
+		os.Exit(1)
+	}
+	if followURL != "" {
+		// The conductor says when the downbeats are.
+		countInBars, countInBeats = 0, 0
+	}
+
+	// Sanity check the gaps
+	if gapPlay < 0 || gapSilent < 0 || (gapSilent > 0 && gapPlay == 0) {
+		fmt.Printf("Requested gaps of %d bars on and %d off are not valid. Must be at least a bar on, and zero or more off\n", gapPlay, gapSilent)
+		os.Exit(1)
+	}
+	if !(gapRandom >= 0 && gapRandom <= 100) {
+		fmt.Printf("Requested random drops of %s%% are not valid. Must be 0-100\n", strconv.FormatFloat(gapRandom, 'f', -1, 64))
+		os.Exit(1)
+	}
+
+	// Sanity check the practice timer
+	if practiceFor < 0 || practiceBars < 0 || practiceBreak < 0 || (practiceFor > 0 && practiceBars > 0) {
+		fmt.Printf("Requested practice timer of %s, or %d bars, with a %s break is not valid. Must be a time or bars, not both, and no less than zero\n", practiceFor, practiceBars, practiceBreak)
+		os.Exit(1)
+	}
+	if followURL != "" {
+		// The conductor says when to stop.
+		practiceFor, practiceBars, practiceBreak = 0, 0, 0
+	}
+
+	// Sanity check the tap-along game
+	if tapRoundTaps < 1 {
+		fmt.Printf("Requested tap-along round of %d taps is not valid. Must be at least 1\n", tapRoundTaps)
+		os.Exit(1)
+	}
+
+	// Sanity check the voice, and load it
+	if voiceName != "" {
+		v, err := loadVoice(voiceName, voiceOnly, voiceSub)
+		if err != nil {
+			fmt.Printf("Requested voice is not valid: %s\n", err)
+			os.Exit(1)
+		}
+		countVoice = v
+	}
+
+	// Sanity check controlMode
+	switch controlMode {
+	case "":
+	case "stdio":
+		if !headless {
+			fmt.Printf("Control mode '%s' requires --headless\n", controlMode)
+			os.Exit(1)
+		}
+	default:
+		fmt.Printf("Requested control mode '%s' is not valid. Must be one of: stdio\n", controlMode)
+		os.Exit(1)
+	}
+	// Sanity check clickModes, and take them to heart
+	clickAudio, clickBell, clickFlash = false, false, false
+	for _, m := range strings.Split(clickModes, ",") {
+		switch strings.TrimSpace(m) {
+		case "audio":
+			clickAudio = true
+		case "bell":
+			clickBell = true
+		case "flash":
+			clickFlash = true
+		default:
+			fmt.Printf("Requested click mode '%s' is not valid. Must be any of: audio, bell, flash\n", m)
+			os.Exit(1)
+		}
+	}
+
+	if headless && controlMode == "" && listenAddr == "" && !socketOn && !mprisOn && followURL == "" {
+		fmt.Printf("Headless requires something to control it: --control, --listen, --socket, --mpris, or --follow\n")
+		os.Exit(1)
+	}
+}
+
+// startControl starts any optional control surfaces. ctl must be set up first.
+func startControl() {
+	if listenAddr != "" {
+		if runAPIfunc == nil {
+			panic(fmt.Errorf("API requested but unhinged"))
+		}
+		if err := runAPIfunc(listenAddr); err != nil {
+			fmt.Printf("Could not listen on '%s': %s\n", listenAddr, err)
+			os.Exit(1)
+		}
+	}
+
+	if mprisOn {
+		if runMPRISfunc == nil {
+			fmt.Printf("MPRIS is only available on Linux\n")
+			os.Exit(1)
+		}
+		if err := runMPRISfunc(); err != nil {
+			fmt.Printf("Could not register with MPRIS: %s\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if socketOn {
+		if runSocketfunc == nil {
+			panic(fmt.Errorf("socket requested but unhinged"))
+		}
+		if err := runSocketfunc(socketPath); err != nil {
+			fmt.Printf("Could not listen on '%s': %s\n", socketPath, err)
+			os.Exit(1)
+		}
+	}
+
+	if followURL != "" {
+		runFollow(followURL)
+	}
+}
+
+// stopControl closes the control surfaces startControl started.
+func stopControl() {
+	for _, c := range controlClosers {
+		c.Close()
+	}
+	controlClosers = nil
+}
+
+// beatString returns the hit pattern with every beat on, e.g. "1234", or "1,2,...,12".
 func beatString(beatsPerMeasure int32) string {
-	var beats string
-	for n := range int(beatsPerMeasure) {
-		beats += strconv.Itoa(n + 1)
+	return statesPattern(slices.Repeat([]beatState{beatOn}, int(beatsPerMeasure)))
+}
+
+// beatState is how a beat is played, in a hit pattern.
+type beatState int
+
+const (
+	beatOff    beatState = iota // not at all
+	beatOn                      // as usual
+	beatAccent                  // accented, e.g. "1>", which clicks accentSound
+)
+
+// next returns the state after s, round and round.
+func (s beatState) next() beatState {
+	return (s + 1) % 3
+}
+
+// patternStates returns how each beat is played in pattern, in a measure of beats.
+func patternStates(pattern string, beats int32) []beatState {
+	played := patternBeats(pattern)
+	states := make([]beatState, beats)
+	for i := range states {
+		states[i] = played[i+1] // beatOff, if it isn't
 	}
-	return beats
+	return states
+}
+
+// patternBeats returns the beats pattern plays, and how. The beats are separated by commas
+// or spaces, e.g. "1>,3,10", or, with neither, are a digit each, e.g. "1>3".
+func patternBeats(pattern string) map[int]beatState {
+	var fields []string
+	if strings.ContainsAny(pattern, ", ") {
+		fields = strings.FieldsFunc(pattern, func(r rune) bool { return r == ',' || r == ' ' })
+	} else {
+		for _, r := range pattern {
+			if r == '>' && len(fields) > 0 {
+				fields[len(fields)-1] += ">"
+			} else {
+				fields = append(fields, string(r))
+			}
+		}
+	}
+
+	played := make(map[int]beatState)
+	for _, f := range fields {
+		state := beatOn
+		if strings.HasSuffix(f, ">") {
+			state = beatAccent
+		}
+		if beat, err := strconv.Atoi(strings.TrimRight(f, ">")); err == nil && beat > 0 {
+			played[beat] = max(played[beat], state)
+		}
+	}
+	return played
+}
+
+// statesPattern returns the hit pattern for states, undoing patternStates.
+func statesPattern(states []beatState) string {
+	var hits []string
+	for i, s := range states {
+		switch s {
+		case beatOn:
+			hits = append(hits, strconv.Itoa(i+1))
+		case beatAccent:
+			hits = append(hits, strconv.Itoa(i+1)+">")
+		}
+	}
+
+	if len(states) > 9 {
+		// 1 and 10 run together otherwise
+		return strings.Join(hits, ",")
+	}
+	return strings.Join(hits, "")
 }
 
 func beatStringToTickFilter(beatString string) func(int) bool {
+	played := patternBeats(beatString)
 	tf := func(beat int) bool {
-		return strings.Contains(beatString, strconv.Itoa(beat))
+		_, ok := played[beat]
+		return ok
 	}
 	return tf
 }
 
+// countInOptions are the count-ins the GUI offers, indexed by bars.
+var countInOptions = []string{"No count-in", "Count in 1 bar", "Count in 2 bars", "Count in 3 bars", "Count in 4 bars"}
+
+// extraWidgets are the widgets setupActions builds by hand, because the GUI
+// builder can't, and so they can't live in gui (see main.gui.go).
+type extraWidgets struct {
+	tsp      *widget.SelectEntry // time signature picker
+	beats    *fyne.Container     // a button per beat, for the hit pattern
+	lights   *guiLights          // see gui_lights.go
+	tempo    *guiTempo           // see gui_tempo.go
+	countIn  *widget.Select      // bars to count in, by countInOptions
+	present  *guiPresent         // see gui_present.go
+	practice *widget.Label       // the practice timer's countdown (see gui_practice.go)
+	game     guiGame             // see gui_tapgame.go
+}
+
 // here you can add some button / callbacks code using widget IDs
 func (g *gui) setupActions() {
-	g.restartButton.Disable()
-	g.pauseButton.Disable()
-
 	g.win.SetTitle("MetroGnome")
 
 	// Pull the list of instruments and set the picker :)
@@ -116,77 +444,209 @@ func (g *gui) setupActions() {
 	g.soundSelect.Options = sounds.Keys()
 	g.soundSelect.Selected = startSound
 	g.soundSelect.OnChanged = func(sound string) {
-		// Get a buffer and pass it on
-		buff := gnome.RPool.Get()
-		buff.Reset(*sounds[sound])
-		err := mg.ReplaceStreamerFromBuffer(buff)
-		if err != nil {
+		if err := ctl.SetSound(sound); err != nil {
 			dialog.ShowError(err, g.win)
 			return
 		}
 	}
 	g.soundSelect.Refresh()
 
+	// The tempo, exactly, or a little, for when Faster and Slower are too much
+	g.labelBox.Add(g.newTempo())
+
 	// Set up the time signature picker
 	// We pre-populate the most commons sigs, but support entry too.
 	// apptrix (Fyne UI) doesn't support SelectEntry, so we programatically add this to the box.
 	tsp := widget.NewSelectEntry([]string{"2/2", "2/4", "3/4", "4/4", "6/8"})
 	tsp.SetText(fmt.Sprintf("%d/4", beatsPerMeasure)) // default
 	tsp.OnChanged = func(ts string) {
-		err := mg.TS.FromString(ts)
-		if err != nil {
+		if err := ctl.SetSignature(ts); err != nil {
 			// Mask the potentially nerdy error
 			dialog.ShowError(fmt.Errorf(" Invalid Signature"), g.win)
 			return
 		}
-		beats := mg.TS.Beats.Load()
-		g.setHitEntry(beatString(beats))
-		g.hitEntry.Refresh()
-		g.ChangeStat()            // Update the stat label
-		g.pb.Max = float64(beats) // Update the progressbar, as the beat count may have changed.
 	}
+	extra.tsp = tsp
 	g.labelBox.Add(tsp)
+
+	// The hit pattern, a button per beat, for those who'd rather not type it (see syncBeats)
+	extra.beats = container.NewHBox()
+	g.labelBox.Add(extra.beats)
+
+	// A light per beat too, and the gnome can bounce along
+	g.labelBox.Add(g.newLights())
+
+	// A bar or two before the first downbeat, for the band
+	extra.countIn = widget.NewSelect(countInOptions, func(picked string) {
+		// Keeping --count-in-beats, which the picker doesn't
+		if err := ctl.SetCountIn(slices.Index(countInOptions, picked), ctl.Status().CountOnly); err != nil {
+			dialog.ShowError(err, g.win)
+		}
+	})
+	g.labelBox.Add(extra.countIn)
+
+	// Gaps in the click, for keeping time on your own, the practice timer, the log, and the game
+	g.labelBox.Add(container.NewHBox(
+		widget.NewButton("Gaps...", g.gapTap),
+		widget.NewButton("Timer...", g.timerTap),
+		widget.NewButton("History...", g.historyTap),
+		widget.NewButton("Tap Along...", g.gameTap),
+	))
+	g.labelBox.Add(g.newPractice())
+
+	// Fullscreen, for the projector (F11 too, and Esc gets you out)
+	g.newPresent()
+	g.labelBox.Add(widget.NewButtonWithIcon("Present", theme.ViewFullScreenIcon(), g.togglePresent))
+
+	// The keyboard, the TUI's keys (see gui_keys.go)
+	g.win.Canvas().SetOnTypedKey(g.typedKey)
+	g.win.Canvas().SetOnTypedRune(g.typedRune)
 	g.labelBox.Refresh()
 
 	// Setup the Gnome!
-	var gerr error
-	mg, gerr = g.gnomeSetup()
-	if gerr != nil {
-		// We are deep in setup. An error here is terminal.
-		panic(gerr)
+	mg = g.gnomeSetup()
+	ctl = newControl(mg)
+	if audioErr != nil {
+		// Not terminal, just quiet.
+		dialog.ShowError(fmt.Errorf("No audio, so clicking silently: %w", audioErr), g.win)
 	}
 
-	// Setup the stat label
-	g.ChangeStat()
+	// Whoever changes things (us, or some other control surface), keep the widgets in sync.
+	ctl.onChange = func(s controlStatus) {
+		fyne.Do(func() { g.syncState(s) })
+	}
+	ctl.onPracticeDone = practiceDone
+	go g.practiceClock()
 
 	// Setup the hitEntry
 	g.setHitEntry(beatString(beatsPerMeasure))
 	g.hitEntry.OnChanged = func(beatString string) {
-		tf := beatStringToTickFilter(beatString)
-		if err := mg.SetTickFilter(tf); err != nil {
-			// The only error is if tf is nil. Impossible!
-			panic(err)
+		if err := ctl.SetPattern(beatString); err != nil {
+			dialog.ShowError(err, g.win)
 		}
 	}
 
 	// Set the progressbar text to be more musical and less percenty.
 	g.pb.TextFormatter = func() string {
+		if ctl.CountIn() > 0 {
+			return fmt.Sprintf("Count-in %.0f", g.pb.Value)
+		}
+		if ctl.Gap() {
+			return fmt.Sprintf("Gap %.0f", g.pb.Value)
+		}
 		return fmt.Sprintf("%.0f", g.pb.Value)
 	}
-	g.pb.Max = float64(mg.TS.Beats.Load()) // Update the progress bar to track beat count
 	g.pb.SetValue(0)
+
+	// Setup the buttons, stat label, and progress bar
+	g.syncState(ctl.Status())
 }
 
 func (g *gui) setHitEntry(beatString string) {
 	g.hitEntry.Text = beatString
 	g.hitEntry.Refresh()
-	tf := beatStringToTickFilter(beatString)
-	if err := mg.SetTickFilter(tf); err != nil {
-		// The only error is if tf is nil. Impossible!
+	if err := ctl.SetPattern(beatString); err != nil {
+		// We only ever set this to a beatString. Impossible!
 		panic(err)
 	}
 }
 
+// syncState makes the widgets reflect s, regardless of which control surface changed it.
+func (g *gui) syncState(s controlStatus) {
+	setEnabled(g.startButton, !s.Started)
+	setEnabled(g.stopButton, s.Running)
+	setEnabled(g.pauseButton, s.Running)
+	setEnabled(g.restartButton, s.Started && !s.Running)
+
+	setText(g.pauseButton, "Pause", "Resume", s.Paused)
+	setText(g.muteButton, "Mute", "Unmute", s.Muted)
+	setText(g.panButton, "Pan", "Unpan", s.Panned)
+
+	// Only poke the entries if they're wrong, lest we trample someone typing.
+	if g.hitEntry.Text != s.Pattern {
+		g.hitEntry.Text = s.Pattern
+		g.hitEntry.Refresh()
+	}
+	if extra.tsp.Text != s.Signature {
+		extra.tsp.Text = s.Signature
+		extra.tsp.Refresh()
+	}
+	if g.soundSelect.Selected != s.Sound {
+		g.soundSelect.Selected = s.Sound
+		g.soundSelect.Refresh()
+	}
+
+	g.syncTempo(s.Tempo)
+	if s.CountIn < len(countInOptions) && extra.countIn.Selected != countInOptions[s.CountIn] {
+		extra.countIn.Selected = countInOptions[s.CountIn]
+		extra.countIn.Refresh()
+	}
+	g.syncBeats(s.Pattern)
+	g.syncLights(s.Pattern)
+	g.syncPresent(s)
+	g.syncPractice(s)
+	g.ChangeStat()                          // Update the stat label
+	g.pb.Max = float64(ctl.ts.Beats.Load()) // Update the progressbar, as the beat count may have changed.
+	g.pb.Refresh()
+}
+
+// syncBeats makes the beat buttons reflect pattern, making them over if the beat count changed.
+func (g *gui) syncBeats(pattern string) {
+	states := patternStates(pattern, ctl.ts.Beats.Load())
+	if len(extra.beats.Objects) != len(states) {
+		extra.beats.RemoveAll()
+		for i := range states {
+			beat := i + 1
+			extra.beats.Add(widget.NewButton(strconv.Itoa(beat), func() { g.beatTap(beat) }))
+		}
+	}
+
+	for i, state := range states {
+		b := extra.beats.Objects[i].(*widget.Button)
+		text, importance := strconv.Itoa(i+1), widget.MediumImportance
+		switch state {
+		case beatOff:
+			importance = widget.LowImportance
+		case beatAccent:
+			text, importance = text+">", widget.HighImportance
+		}
+		if b.Text != text || b.Importance != importance {
+			b.Text, b.Importance = text, importance
+			b.Refresh()
+		}
+	}
+}
+
+// beatTap moves beat on to its next state: off, on, accented, and round again.
+func (g *gui) beatTap(beat int) {
+	states := patternStates(ctl.Status().Pattern, ctl.ts.Beats.Load())
+	states[beat-1] = states[beat-1].next()
+	if err := ctl.SetPattern(statesPattern(states)); err != nil {
+		dialog.ShowError(err, g.win)
+	}
+}
+
+// setEnabled enables or disables b.
+func setEnabled(b *widget.Button, enabled bool) {
+	if enabled {
+		b.Enable()
+	} else {
+		b.Disable()
+	}
+}
+
+// setText sets b's text to on if state, otherwise off, refreshing if that's a change.
+func setText(b *widget.Button, off, on string, state bool) {
+	text := off
+	if state {
+		text = on
+	}
+	if b.Text != text {
+		b.Text = text
+		b.Refresh()
+	}
+}
+
 // setGnomes changes the musical gnome
 func (g *gui) setGnomes(instrument string) {
 	if bard, ok := gnomes[instrument]; ok {
@@ -197,89 +657,92 @@ func (g *gui) setGnomes(instrument string) {
 
 // ChangeStat updates the statLabel
 func (g *gui) ChangeStat() {
-	g.statLabel.Text = mg.TS.String()
+	g.statLabel.Text = ctl.Status().String()
 	g.statLabel.Refresh()
 }
 
 // gnomeSetup gets a lot of the Gnome-specific setup stuff out of the main setup function.
-func (g *gui) gnomeSetup() (*gnome.Gnome, error) {
-	var (
-		tf   func(int)
-		buff *recyclable.Buffer
-	)
+func (g *gui) gnomeSetup() clicker {
+	// Every time there is a tick, update the pb, and the lights
+	tf := func(beat int) {
+		ctl.tick(beat)
+		fyne.Do(func() {
+			g.pb.SetValue(float64(beat))
+			g.lightBeat(beat)
+			g.presentBeat(beat)
+		})
+	}
+
+	return newGnome(tf)
+}
 
-	// Every time there is a tick, update the pb
-	tf = func(beat int) {
-		fyne.Do(func() { g.pb.SetValue(float64(beat)) })
+// newGnome makes a gnome from the global tunables, that calls tf every tick.
+// If audio isn't wanted, or can't be had (see audioErr), it makes a nullGnome instead,
+// so the time is kept all the same.
+func newGnome(tf func(int)) clicker {
+	if !clickAudio {
+		return newNullGnome(beatsPerMeasure, tempoBPM, tf)
 	}
 
 	// Get a buffer and pass it on
-	buff = gnome.RPool.Get()
+	buff := gnome.RPool.Get()
 	buff.Reset(*sounds[startSound])
 
-	return gnome.NewGnomeFromBuffer(buff, gnome.NewTimeSignature(beatsPerMeasure, 4, tempoBPM), tf)
-
+	a := new(audioGnome)
+	g, err := gnome.NewGnomeFromBuffer(buff, gnome.NewTimeSignature(beatsPerMeasure, 4, int32(math.Round(tempoBPM))),
+		func(beat int) {
+			a.tick()
+			tf(beat)
+		})
+	if err != nil {
+		// stderr, lest we trample --control=stdio
+		audioErr = err
+		fmt.Fprintf(os.Stderr, "Could not open the audio, so clicking silently: %s\n", err)
+		return newNullGnome(beatsPerMeasure, tempoBPM, tf)
+	}
+	a.Gnome = g
+	a.Change(tempoBPM)
+	return a
 }
 
 func (g *gui) startTap() {
-	g.startButton.Disable()
-	g.pauseButton.Text = "Pause" // might be "Resume"
-	g.pauseButton.Enable()
-	mg.Start()
+	if err := ctl.Start(); err != nil {
+		dialog.ShowError(err, g.win)
+	}
 }
 
 func (g *gui) stopTap() {
-	g.stopButton.Disable()
-	g.pauseButton.Disable()
-	mg.Stop()
-	g.restartButton.Enable()
+	if err := ctl.Stop(); err != nil {
+		dialog.ShowError(err, g.win)
+	}
 }
 
 // toggle
 func (g *gui) pauseTap() {
-	mg.Pause()
-	if g.pauseButton.Text == "Pause" {
-		g.pauseButton.Text = "Resume"
-	} else {
-		g.pauseButton.Text = "Pause"
+	if err := ctl.Pause(); err != nil {
+		dialog.ShowError(err, g.win)
 	}
-	g.pauseButton.Refresh()
 }
 
 func (g *gui) upTap() {
-	new := mg.TS.Tempo.Add(tempoDelta)
-	mg.Change(new)
-	g.ChangeStat()
+	// NudgeTempo stops at the bounds, and only complains if we're there already,
+	// which the slider shows, and is no reason to bother anyone.
+	ctl.NudgeTempo(tempoDelta)
 }
 
 func (g *gui) downTap() {
-	new := mg.TS.Tempo.Add(-1 * tempoDelta)
-	if new <= 0 {
-		// Safety
-		mg.TS.Tempo.Add(tempoDelta)
-	} else {
-		mg.Change(new)
-		g.ChangeStat()
-	}
+	ctl.NudgeTempo(-1 * tempoDelta)
 }
 
 func (g *gui) restartTap() {
-	g.restartButton.Disable()
-	mg.Restart()
-	g.stopButton.Enable()
-	g.pauseButton.Text = "Pause" // might be "Resume"
-	g.pauseButton.Enable()
+	if err := ctl.Restart(); err != nil {
+		dialog.ShowError(err, g.win)
+	}
 }
 
 // toggle
 func (g *gui) muteAction() {
-	mg.Mute()
-	if g.muteButton.Text == "Mute" {
-		g.muteButton.Text = "Unmute"
-	} else {
-		g.muteButton.Text = "Mute"
-	}
-	g.muteButton.Refresh()
+	ctl.Mute()
 }
 
 func (g *gui) helpTap() {
@@ -289,12 +752,7 @@ func (g *gui) helpTap() {
 	}
 }
 
+// toggle
 func (g *gui) panTap() {
-	mg.Pan()
-	if g.panButton.Text == "Pan" {
-		g.panButton.Text = "Unpan"
-	} else {
-		g.panButton.Text = "Pan"
-	}
-	g.panButton.Refresh()
+	ctl.Pan()
 }
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestPatternStates(t *testing.T) {
+	const (
+		off = beatOff
+		on  = beatOn
+		acc = beatAccent
+	)
+	tests := []struct {
+		pattern string
+		beats   int32
+		want    []beatState
+	}{
+		{"1234567", 7, []beatState{on, on, on, on, on, on, on}},
+		{"1>23>4567", 7, []beatState{acc, on, acc, on, on, on, on}},
+		{"1357", 7, []beatState{on, off, on, off, on, off, on}},
+		{"1 3 5", 7, []beatState{on, off, on, off, on, off, off}},
+		{"", 7, []beatState{off, off, off, off, off, off, off}},
+		{"1,2,3,4,5,6,7,8,9,10,11,12", 12, []beatState{on, on, on, on, on, on, on, on, on, on, on, on}},
+		{"3,4,5,6,7,8,9,10,11,12", 12, []beatState{off, off, on, on, on, on, on, on, on, on, on, on}},
+		{"1>,4>,7>,10>", 12, []beatState{acc, off, off, acc, off, off, acc, off, off, acc, off, off}},
+		{"10,11,12", 12, []beatState{off, off, off, off, off, off, off, off, off, on, on, on}},
+		{"2,12>", 12, []beatState{off, on, off, off, off, off, off, off, off, off, off, acc}},
+	}
+	for _, tt := range tests {
+		got := patternStates(tt.pattern, tt.beats)
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("patternStates(%q, %d) = %v, want %v", tt.pattern, tt.beats, got, tt.want)
+		}
+		if again := statesPattern(got); !slices.Equal(patternStates(again, tt.beats), got) {
+			t.Errorf("statesPattern(patternStates(%q, %d)) = %q, which doesn't come back the same", tt.pattern, tt.beats, again)
+		}
+	}
+}
+
+func TestBeatStringToTickFilter(t *testing.T) {
+	tests := []struct {
+		pattern string
+		on, off []int
+	}{
+		{beatString(7), []int{1, 2, 3, 4, 5, 6, 7}, []int{8}},
+		{"1>3", []int{1, 3}, []int{2}},
+		{beatString(12), []int{1, 2, 9, 10, 11, 12}, []int{13}},
+		{"3,4,5,6,7,8,9,10,11,12", []int{3, 10, 11, 12}, []int{1, 2}},
+		{"10>,12", []int{10, 12}, []int{1, 2, 11}},
+	}
+	for _, tt := range tests {
+		tf := beatStringToTickFilter(tt.pattern)
+		for _, b := range tt.on {
+			if !tf(b) {
+				t.Errorf("beatStringToTickFilter(%q)(%d) = false, want true", tt.pattern, b)
+			}
+		}
+		for _, b := range tt.off {
+			if tf(b) {
+				t.Errorf("beatStringToTickFilter(%q)(%d) = true, want false", tt.pattern, b)
+			}
+		}
+	}
+}
@@ -0,0 +1,232 @@
+//go:build linux && !wasm
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/godbus/dbus/v5/introspect"
+	"github.com/godbus/dbus/v5/prop"
+)
+
+const (
+	mprisName        = "org.mpris.MediaPlayer2.metrognome"
+	mprisPath        = dbus.ObjectPath("/org/mpris/MediaPlayer2")
+	mprisRootIface   = "org.mpris.MediaPlayer2"
+	mprisPlayerIface = "org.mpris.MediaPlayer2.Player"
+	mprisTrackID     = dbus.ObjectPath("/com/cognusion/metrognome/gnome")
+)
+
+func init() {
+	// runMPRISfunc is defined as a dangling var in main.
+	// it gets attached IFF linux && !wasm, as nobody
+	// else has a session bus to speak of.
+	runMPRISfunc = runMPRIS
+}
+
+// runMPRIS registers us as an MPRIS player on the session bus, so media keys and
+// widgets can work the gnome, and keeps the bus up to date in the background.
+// The current tempo and signature are the "track".
+func runMPRIS() error {
+	conn, err := dbus.ConnectSessionBus()
+	if err != nil {
+		return err
+	}
+	return serveMPRIS(conn)
+}
+
+// serveMPRIS does the heavy lifting for runMPRIS, on conn.
+func serveMPRIS(conn *dbus.Conn) error {
+	s := ctl.Status()
+
+	// We're no more of a player than play, pause, and stop need,
+	// so most of the capabilities are false.
+	props, err := prop.Export(conn, mprisPath, prop.Map{
+		mprisRootIface: {
+			"CanQuit":             {Value: false},
+			"CanRaise":            {Value: false},
+			"HasTrackList":        {Value: false},
+			"Identity":            {Value: "MetroGnome"},
+			"SupportedUriSchemes": {Value: []string{}},
+			"SupportedMimeTypes":  {Value: []string{}},
+		},
+		mprisPlayerIface: {
+			"PlaybackStatus": {Value: mprisPlaybackStatus(s), Emit: prop.EmitTrue},
+			"LoopStatus":     {Value: "Track"},
+			"Rate":           {Value: 1.0},
+			"Shuffle":        {Value: false},
+			"Metadata":       {Value: mprisMetadata(s), Emit: prop.EmitTrue},
+			"Volume":         {Value: 1.0},
+			"Position":       {Value: int64(0), Emit: prop.EmitFalse},
+			"MinimumRate":    {Value: 1.0},
+			"MaximumRate":    {Value: 1.0},
+			"CanGoNext":      {Value: false},
+			"CanGoPrevious":  {Value: false},
+			"CanPlay":        {Value: true},
+			"CanPause":       {Value: true},
+			"CanSeek":        {Value: false},
+			"CanControl":     {Value: true},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := conn.Export(mprisRoot{}, mprisPath, mprisRootIface); err != nil {
+		return err
+	}
+	// Seek would trip up vet, being not io.Seeker's, so its Go name is SeekBy.
+	if err := conn.ExportWithMap(mprisPlayer{}, map[string]string{"SeekBy": "Seek"}, mprisPath, mprisPlayerIface); err != nil {
+		return err
+	}
+	playerMethods := introspect.Methods(mprisPlayer{})
+	for i := range playerMethods {
+		if playerMethods[i].Name == "SeekBy" {
+			playerMethods[i].Name = "Seek"
+		}
+	}
+
+	node := &introspect.Node{
+		Name: string(mprisPath),
+		Interfaces: []introspect.Interface{
+			introspect.IntrospectData,
+			prop.IntrospectData,
+			{
+				Name:       mprisRootIface,
+				Methods:    introspect.Methods(mprisRoot{}),
+				Properties: props.Introspection(mprisRootIface),
+			},
+			{
+				Name:       mprisPlayerIface,
+				Methods:    playerMethods,
+				Properties: props.Introspection(mprisPlayerIface),
+			},
+		},
+	}
+	if err := conn.Export(introspect.NewIntrospectable(node), mprisPath, "org.freedesktop.DBus.Introspectable"); err != nil {
+		return err
+	}
+
+	// Only ask for the name once everything behind it is ready.
+	reply, err := conn.RequestName(mprisName, dbus.NameFlagDoNotQueue)
+	if err != nil {
+		return err
+	}
+	if reply != dbus.RequestNameReplyPrimaryOwner {
+		return fmt.Errorf("%s is already taken", mprisName)
+	}
+	controlClosers = append(controlClosers, conn) // which gives the name back
+
+	// Keep the bus posted, until it's closed.
+	events := ctl.Subscribe()
+	go func() {
+		defer ctl.Unsubscribe(events)
+		for {
+			select {
+			case <-conn.Context().Done():
+				return
+			case e := <-events:
+				if e.Type != "state" {
+					continue
+				}
+				props.SetMust(mprisPlayerIface, "PlaybackStatus", mprisPlaybackStatus(*e.Status))
+				props.SetMust(mprisPlayerIface, "Metadata", mprisMetadata(*e.Status))
+			}
+		}
+	}()
+	return nil
+}
+
+// mprisPlaybackStatus is s, as MPRIS sees it.
+func mprisPlaybackStatus(s controlStatus) string {
+	switch {
+	case s.Running && s.Paused:
+		return "Paused"
+	case s.Running:
+		return "Playing"
+	}
+	return "Stopped"
+}
+
+// mprisMetadata is the "track" for s.
+func mprisMetadata(s controlStatus) map[string]dbus.Variant {
+	return map[string]dbus.Variant{
+		"mpris:trackid": dbus.MakeVariant(mprisTrackID),
+		"xesam:title":   dbus.MakeVariant(fmt.Sprintf("%s BPM in %s", fmtTempo(s.Tempo), s.Signature)),
+		"xesam:artist":  dbus.MakeVariant([]string{"MetroGnome"}),
+		"xesam:album":   dbus.MakeVariant(s.Sound),
+	}
+}
+
+// mprisRoot is org.mpris.MediaPlayer2, which we can neither Raise nor Quit.
+type mprisRoot struct{}
+
+// Raise is a noop.
+func (mprisRoot) Raise() *dbus.Error { return nil }
+
+// Quit is a noop.
+func (mprisRoot) Quit() *dbus.Error { return nil }
+
+// mprisPlayer is org.mpris.MediaPlayer2.Player.
+type mprisPlayer struct{}
+
+// Play starts, or resumes, the gnome.
+func (mprisPlayer) Play() *dbus.Error {
+	s := ctl.Status()
+	switch {
+	case !s.Running:
+		return mprisError(ctl.Start())
+	case s.Paused:
+		return mprisError(ctl.Pause())
+	}
+	return nil
+}
+
+// Pause pauses the gnome, if it's playing.
+func (mprisPlayer) Pause() *dbus.Error {
+	if s := ctl.Status(); s.Running && !s.Paused {
+		return mprisError(ctl.Pause())
+	}
+	return nil
+}
+
+// PlayPause starts, pauses, or resumes, the gnome.
+func (p mprisPlayer) PlayPause() *dbus.Error {
+	if s := ctl.Status(); s.Running && !s.Paused {
+		return p.Pause()
+	}
+	return p.Play()
+}
+
+// Stop stops the gnome, if it's running.
+func (mprisPlayer) Stop() *dbus.Error {
+	if ctl.Status().Running {
+		return mprisError(ctl.Stop())
+	}
+	return nil
+}
+
+// Next is a noop.
+func (mprisPlayer) Next() *dbus.Error { return nil }
+
+// Previous is a noop.
+func (mprisPlayer) Previous() *dbus.Error { return nil }
+
+// SeekBy is Seek, a noop.
+func (mprisPlayer) SeekBy(offset int64) *dbus.Error { return nil }
+
+// SetPosition is a noop.
+func (mprisPlayer) SetPosition(track dbus.ObjectPath, position int64) *dbus.Error { return nil }
+
+// OpenUri is a noop.
+func (mprisPlayer) OpenUri(uri string) *dbus.Error { return nil }
+
+// mprisError wraps err for the bus, if there is one.
+func mprisError(err error) *dbus.Error {
+	if err != nil {
+		ctl.reportError(err)
+		return dbus.MakeFailedError(err)
+	}
+	return nil
+}
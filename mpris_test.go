@@ -0,0 +1,118 @@
+//go:build linux && !wasm
+
+package main
+
+import (
+	"bufio"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// privateBus starts a session bus of our own, for the test, and returns its address.
+func privateBus(t *testing.T) string {
+	if _, err := exec.LookPath("dbus-daemon"); err != nil {
+		t.Skip("no dbus-daemon")
+	}
+	cmd := exec.Command("dbus-daemon", "--session", "--nofork", "--print-address",
+		"--address=unix:path="+filepath.Join(t.TempDir(), "bus"))
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	})
+	addr, err := bufio.NewReader(out).ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.TrimSpace(addr)
+}
+
+// busConn connects to the bus at addr, as a client would.
+func busConn(t *testing.T, addr string) *dbus.Conn {
+	conn, err := dbus.Connect(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return conn
+}
+
+// eventually fails t if ok isn't true within a second.
+func eventually(t *testing.T, what string, ok func() bool) {
+	t.Helper()
+	for deadline := time.Now().Add(time.Second); !ok(); {
+		if time.Now().After(deadline) {
+			t.Fatalf("%s, still", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestMPRIS(t *testing.T) {
+	addr := privateBus(t)
+	ctl = newTestControl()
+	defer ctl.Stop()
+
+	conn := busConn(t, addr)
+	if err := serveMPRIS(conn); err != nil {
+		t.Fatal(err)
+	}
+	defer stopControl()
+
+	client := busConn(t, addr)
+	defer client.Close()
+	player := client.Object(mprisName, mprisPath)
+	property := func(name string) any {
+		v, err := player.GetProperty(mprisPlayerIface + "." + name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v.Value()
+	}
+
+	if got := property("PlaybackStatus"); got != "Stopped" {
+		t.Errorf("PlaybackStatus = %v, want Stopped", got)
+	}
+	if got := property("Rate"); got != 1.0 {
+		t.Errorf("Rate = %v, want 1", got)
+	}
+
+	for _, tt := range []struct {
+		method, want string
+	}{
+		{"Play", "Playing"},
+		{"Pause", "Paused"},
+		{"PlayPause", "Playing"},
+		{"Stop", "Stopped"},
+	} {
+		if err := player.Call(mprisPlayerIface+"."+tt.method, 0).Err; err != nil {
+			t.Fatalf("%s: %s", tt.method, err)
+		}
+		if got := mprisPlaybackStatus(ctl.Status()); got != tt.want {
+			t.Errorf("after %s, the gnome is %s, want %s", tt.method, got, tt.want)
+		}
+		eventually(t, "PlaybackStatus is behind after "+tt.method, func() bool { return property("PlaybackStatus") == tt.want })
+	}
+
+	// Closing the bus stops the events.
+	subs := func() int {
+		ctl.emu.Lock()
+		defer ctl.emu.Unlock()
+		return len(ctl.subs)
+	}
+	if subs() != 1 {
+		t.Fatalf("%d subscribers, want 1", subs())
+	}
+	stopControl()
+	eventually(t, "subscribed after the bus closed", func() bool { return subs() == 0 })
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The practice timer stops the gnome after so long playing, or after so many bars, and
+// tells everyone it's done. With a break, it starts again from the top once that's over.
+// Pauses don't count, and (re)starts start the timer over.
+
+// SetPractice sets the practice timer to stop after d of playing, or after bar bars (if
+// not zero, and d is), and then, if brk isn't zero, start again after a break that long.
+// Zero d and bars is no timer.
+func (c *control) SetPractice(d time.Duration, bars int, brk time.Duration) error {
+	if d < 0 || bars < 0 || brk < 0 {
+		return fmt.Errorf("practice timer must be zero or more, not %s, %d bars, and a break of %s", d, bars, brk)
+	}
+	if d > 0 && bars > 0 {
+		return fmt.Errorf("practice for so long, or so many bars, not both")
+	}
+
+	c.mu.Lock()
+	c.practiceFor, c.practiceBreak = d, brk
+	c.breakUntil = time.Time{}
+	if c.running && !c.g.IsPaused() {
+		// Carry on timing, from the new timer.
+		c.practiceHalt()
+		c.practiceGo()
+	} else {
+		c.practiceGen++ // calls off a break, if there was one
+	}
+	c.mu.Unlock()
+
+	c.emu.Lock()
+	c.practiceBars = bars
+	c.emu.Unlock()
+
+	c.changed()
+	return nil
+}
+
+// practiceLeft returns how long is left to practice, if there's a timer. Call it holding mu.
+func (c *control) practiceLeft() time.Duration {
+	if c.practiceFor == 0 {
+		return 0
+	}
+	done := c.practiced
+	if !c.practiceSince.IsZero() {
+		done += time.Since(c.practiceSince)
+	}
+	return max(c.practiceFor-done, 0)
+}
+
+// practiceGo starts timing, as the gnome starts or resumes. Call it holding mu.
+func (c *control) practiceGo() {
+	c.practiceSince = time.Now()
+	c.breakUntil = time.Time{}
+	c.practiceGen++
+	if c.practiceFor == 0 {
+		return
+	}
+	gen, left := c.practiceGen, c.practiceLeft()
+	time.AfterFunc(left, func() {
+		c.mu.Lock()
+		if gen != c.practiceGen {
+			// Paused, stopped, or set since.
+			c.mu.Unlock()
+			return
+		}
+		c.practiceDone()
+	})
+}
+
+// practiceHalt stops timing, as the gnome stops or pauses. Call it holding mu.
+func (c *control) practiceHalt() {
+	if !c.practiceSince.IsZero() {
+		c.practiced += time.Since(c.practiceSince)
+		c.practiceSince = time.Time{}
+	}
+	c.practiceGen++
+}
+
+// practiceBar stops the gnome after bar, the one that just ended, if that's where the
+// practice timer says to. Call it holding emu, from tick.
+func (c *control) practiceBar(bar int) {
+	if c.practiceBars == 0 || bar < c.practiceBars {
+		return
+	}
+	// Not here, as whoever holds mu may be waiting on the gnome, and we may be the gnome.
+	// Halfway to the next beat is after this one's click, and before the next.
+	starts, wait := c.starts, c.Period()/2
+	time.AfterFunc(wait, func() {
+		c.mu.Lock()
+		c.emu.Lock()
+		stale := starts != c.starts
+		c.emu.Unlock()
+		if stale || !c.running {
+			c.mu.Unlock()
+			return
+		}
+		c.practiceDone()
+	})
+}
+
+// practiceDone stops the gnome, as practice is over, and starts the break, if there is one.
+// Call it holding mu, which it unlocks.
+func (c *control) practiceDone() {
+	if c.running {
+		c.sessionEnd()
+		c.g.Stop()
+		c.stopCountIn()
+		c.running = false
+	}
+	c.practiceHalt()
+	msg := "Practice done"
+	if c.practiceBreak > 0 {
+		c.breakUntil = time.Now().Add(c.practiceBreak)
+		gen := c.practiceGen
+		time.AfterFunc(c.practiceBreak, func() {
+			c.mu.Lock()
+			stale := gen != c.practiceGen || c.running
+			c.mu.Unlock()
+			if !stale {
+				c.Restart()
+			}
+		})
+		msg += ", back in " + fmtClock(c.practiceBreak)
+	}
+	onDone := c.onPracticeDone
+	c.mu.Unlock()
+
+	c.changed()
+	c.emu.Lock()
+	c.broadcast(controlEvent{Type: "practice", Time: time.Now()})
+	c.emu.Unlock()
+	if onDone != nil {
+		onDone(msg)
+	}
+}
+
+// parsePractice parses a practice timer as the practice command and the TUI take them:
+// how long (minutes, or a duration like 90s), or "bar" and which, then maybe a break,
+// e.g. "10", "10m 2m", "bar 32", or "off".
+func parsePractice(s string) (d time.Duration, bars int, brk time.Duration, err error) {
+	fields := strings.Fields(strings.ToLower(s))
+	bad := fmt.Errorf("invalid practice timer %q, must be minutes, or bar and which, and maybe a break, e.g. 10 2, or bar 32", s)
+	switch {
+	case len(fields) == 1 && fields[0] == "off":
+		return 0, 0, 0, nil
+	case len(fields) == 0:
+		return 0, 0, 0, bad
+	case fields[0] == "bar" || fields[0] == "bars":
+		if len(fields) < 2 {
+			return 0, 0, 0, bad
+		}
+		if bars, err = strconv.Atoi(fields[1]); err != nil || bars < 1 {
+			return 0, 0, 0, bad
+		}
+		fields = fields[2:]
+	default:
+		if d, err = parseMinutes(fields[0]); err != nil {
+			return 0, 0, 0, bad
+		}
+		fields = fields[1:]
+	}
+	switch len(fields) {
+	case 0:
+	case 1:
+		if brk, err = parseMinutes(fields[0]); err != nil {
+			return 0, 0, 0, bad
+		}
+	default:
+		return 0, 0, 0, bad
+	}
+	return d, bars, brk, nil
+}
+
+// parseMinutes parses a number of minutes, e.g. 2.5, or a duration, e.g. 90s.
+func parseMinutes(s string) (time.Duration, error) {
+	if m, err := strconv.ParseFloat(s, 64); err == nil {
+		return time.Duration(m * float64(time.Minute)).Round(time.Second), nil
+	}
+	return time.ParseDuration(s)
+}
+
+// fmtMinutes formats d as parseMinutes takes it, in minutes if it's whole ones, e.g. 10,
+// or as a duration, e.g. 1m30s.
+func fmtMinutes(d time.Duration) string {
+	if d%time.Minute == 0 {
+		return strconv.Itoa(int(d / time.Minute))
+	}
+	return d.String()
+}
+
+// fmtPractice formats a practice timer as parsePractice takes it, or "off".
+func fmtPractice(d time.Duration, bars int, brk time.Duration) string {
+	var s string
+	switch {
+	case bars > 0:
+		s = "bar " + strconv.Itoa(bars)
+	case d > 0:
+		s = fmtMinutes(d)
+	default:
+		return "off"
+	}
+	if brk > 0 {
+		s += " " + fmtMinutes(brk)
+	}
+	return s
+}
+
+// fmtClock formats d as a clock counting down would, e.g. 9:05, or 1:02:03.
+func fmtClock(d time.Duration) string {
+	s := int((d + time.Second - 1) / time.Second) // round up, so it says 0:00 only at the end
+	if s >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", s/3600, s/60%60, s%60)
+	}
+	return fmt.Sprintf("%d:%02d", s/60, s%60)
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParsePractice(t *testing.T) {
+	tests := []struct {
+		s    string
+		d    time.Duration
+		bars int
+		brk  time.Duration
+		ok   bool
+	}{
+		{"10", 10 * time.Minute, 0, 0, true},
+		{"2.5", 150 * time.Second, 0, 0, true},
+		{"90s", 90 * time.Second, 0, 0, true},
+		{"10 2", 10 * time.Minute, 0, 2 * time.Minute, true},
+		{"10m 30s", 10 * time.Minute, 0, 30 * time.Second, true},
+		{"bar 32", 0, 32, 0, true},
+		{"Bars 32 1", 0, 32, time.Minute, true},
+		{"off", 0, 0, 0, true},
+		{"", 0, 0, 0, false},
+		{"bar", 0, 0, 0, false},
+		{"bar 0", 0, 0, 0, false},
+		{"bar many", 0, 0, 0, false},
+		{"10 2 3", 0, 0, 0, false},
+		{"ten", 0, 0, 0, false},
+	}
+	for _, tt := range tests {
+		d, bars, brk, err := parsePractice(tt.s)
+		if (err == nil) != tt.ok {
+			t.Errorf("parsePractice(%q) = %v, want ok %v", tt.s, err, tt.ok)
+			continue
+		}
+		if d != tt.d || bars != tt.bars || brk != tt.brk {
+			t.Errorf("parsePractice(%q) = %s, %d, %s, want %s, %d, %s", tt.s, d, bars, brk, tt.d, tt.bars, tt.brk)
+		}
+	}
+}
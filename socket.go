@@ -0,0 +1,136 @@
+//go:build !wasm
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+func init() {
+	// runSocketfunc is defined as a dangling var in main.
+	// it gets attached IFF !wasm, for the same reasons
+	// as runAPIfunc.
+	runSocketfunc = runSocket
+}
+
+// socketReply is what the socket answers each command with.
+type socketReply struct {
+	Status *controlStatus `json:"status,omitempty"`
+	Error  string         `json:"error,omitempty"`
+}
+
+// defaultSocketPath is where the socket goes if --socket doesn't say otherwise.
+func defaultSocketPath() string {
+	dir := os.Getenv("XDG_RUNTIME_DIR")
+	if dir == "" {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, fmt.Sprintf("metrognome-%d.sock", os.Getuid()))
+}
+
+// runSocket listens on the Unix socket at path, and serves the control commands (see
+// control.Command) from it in the background. Each command line is answered with a
+// JSON socketReply line.
+func runSocket(path string) error {
+	// If there's a socket there nobody answers, it's left over from a crash.
+	if _, err := os.Stat(path); err == nil {
+		if c, err := net.DialTimeout("unix", path, time.Second); err == nil {
+			c.Close()
+			return fmt.Errorf("another MetroGnome is already listening")
+		}
+		os.Remove(path)
+	}
+
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return err
+	}
+	// Only we get to boss the gnome around.
+	if err := os.Chmod(path, 0600); err != nil {
+		l.Close()
+		return err
+	}
+	controlClosers = append(controlClosers, l) // which removes the socket, too
+
+	go func() {
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go socketConn(c)
+		}
+	}()
+	return nil
+}
+
+// socketConn runs commands from c until it goes away.
+func socketConn(c net.Conn) {
+	defer c.Close()
+
+	enc := json.NewEncoder(c)
+	scanner := bufio.NewScanner(c)
+	for scanner.Scan() {
+		var reply socketReply
+		if err := ctl.Command(scanner.Text()); err != nil {
+			ctl.reportError(err)
+			reply.Error = err.Error()
+		} else {
+			s := ctl.Status()
+			reply.Status = &s
+		}
+		if enc.Encode(reply) != nil {
+			return
+		}
+	}
+}
+
+// runCtl is the `metrognome ctl` client: it sends args as one command to the socket
+// at path, and prints the reply. It returns the exit code.
+func runCtl(path string, args []string) int {
+	if len(args) == 0 {
+		fmt.Printf("Usage: metrognome ctl <command>, e.g. `metrognome ctl tempo +5` or `metrognome ctl status`\n")
+		return 1
+	}
+
+	reply, err := ctlSend(path, strings.Join(args, " "))
+	if err != nil {
+		fmt.Printf("Could not talk to MetroGnome on '%s': %s\n", path, err)
+		return 1
+	}
+	if reply.Error != "" {
+		fmt.Printf("Error: %s\n", reply.Error)
+		return 1
+	}
+
+	out, _ := json.MarshalIndent(reply.Status, "", "  ")
+	fmt.Printf("%s\n", out)
+	return 0
+}
+
+// ctlSend sends command to the socket at path, and returns the reply.
+func ctlSend(path, command string) (socketReply, error) {
+	var reply socketReply
+
+	c, err := net.DialTimeout("unix", path, 5*time.Second)
+	if err != nil {
+		return reply, err
+	}
+	defer c.Close()
+	c.SetDeadline(time.Now().Add(5 * time.Second))
+
+	if _, err := fmt.Fprintf(c, "%s\n", command); err != nil {
+		return reply, err
+	}
+	if err := json.NewDecoder(c).Decode(&reply); err != nil {
+		return reply, fmt.Errorf("no reply: %w", err)
+	}
+	return reply, nil
+}
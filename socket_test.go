@@ -0,0 +1,43 @@
+//go:build !wasm
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSocket(t *testing.T) {
+	ctl = newTestControl()
+	path := filepath.Join(t.TempDir(), "metrognome.sock")
+	if err := runSocket(path); err != nil {
+		t.Fatal(err)
+	}
+	defer stopControl()
+
+	if fi, err := os.Stat(path); err != nil {
+		t.Fatal(err)
+	} else if fi.Mode().Perm() != 0600 {
+		t.Errorf("the socket is %s, want only us", fi.Mode().Perm())
+	}
+	if err := runSocket(path); err == nil {
+		t.Error("a second MetroGnome listened on the same socket")
+	}
+
+	reply, err := ctlSend(path, "tempo 96")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reply.Error != "" || reply.Status == nil || reply.Status.Tempo != 96 {
+		t.Errorf("tempo 96 answered %+v", reply)
+	}
+	if reply, err := ctlSend(path, "dance"); err != nil || reply.Error == "" {
+		t.Errorf("dance answered %+v, %v", reply, err)
+	}
+
+	stopControl()
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("the socket is still there: %v", err)
+	}
+}
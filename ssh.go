@@ -0,0 +1,146 @@
+//go:build !wasm
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/ssh"
+	"github.com/charmbracelet/wish"
+	"github.com/charmbracelet/wish/activeterm"
+	"github.com/charmbracelet/wish/bubbletea"
+	"github.com/charmbracelet/wish/logging"
+	"github.com/spf13/pflag"
+)
+
+// runServeSSH is the `metrognome serve-ssh` subcommand: it serves the TUI to every
+// SSH session, until interrupted. It returns the exit code.
+func runServeSSH(args []string) int {
+	fs := pflag.NewFlagSet("serve-ssh", pflag.ContinueOnError)
+	addr := fs.String("addr", "localhost:2222", "Address to serve SSH on")
+	hostKey := fs.String("host-key", "metrognome_ed25519", "SSH host key path, which is made if it doesn't exist")
+	authorizedKeys := fs.String("authorized-keys", "", "Only let in the public keys in this authorized_keys file (default anyone)")
+	conductor := fs.Bool("conductor", false, "Every session follows one shared gnome (clicking here), instead of getting its own")
+	bell := fs.Bool("bell", true, "Ring the terminal bell on every beat, to start with (sessions can toggle it)")
+	flash := fs.Bool("flash", false, "Flash the background on every beat, to start with (sessions can toggle it)")
+	fs.SortFlags = false
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, pflag.ErrHelp) {
+			return 0
+		}
+		return 1
+	}
+
+	if err := loadTUIConfig(tuiConfigPath); err != nil {
+		fmt.Printf("Could not load the TUI config: %s\n", err)
+		return 1
+	}
+
+	if *conductor {
+		// The shared gnome, which is also the one the control surfaces work on.
+		mg = newGnome(func(beat int) { ctl.tick(beat) })
+		defer mg.Close()
+		ctl = newControl(mg)
+		ctl.SetPattern(beatString(beatsPerMeasure))
+		startControl()
+		defer stopControl()
+		ctl.Start()
+		defer ctl.Stop() // logs the practice session
+	}
+
+	s, err := newSSHServer(*addr, *hostKey, *authorizedKeys, *conductor, *bell, *flash)
+	if err != nil {
+		fmt.Printf("Could not serve SSH: %s\n", err)
+		return 1
+	}
+	if *authorizedKeys == "" && !loopbackAddr(*addr) {
+		fmt.Printf("WARNING: without --authorized-keys, anyone who can reach %s gets a session", *addr)
+		if *conductor {
+			fmt.Printf(", and can drive the shared gnome")
+		}
+		fmt.Printf("\n")
+	}
+
+	done := make(chan os.Signal, 1)
+	signal.Notify(done, os.Interrupt, syscall.SIGTERM)
+
+	fmt.Printf("Serving the TUI over SSH on %s\n", *addr)
+	errc := make(chan error, 1)
+	go func() {
+		errc <- s.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errc:
+		fmt.Printf("Could not serve SSH: %s\n", err)
+		return 1
+	case <-done:
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	s.Shutdown(ctx)
+	return 0
+}
+
+// newSSHServer returns the serve-ssh server, on addr, with the host key at hostKey, letting in
+// the keys in authorizedKeys (or anyone, if it's empty). The rest are serve-ssh's flags.
+func newSSHServer(addr, hostKey, authorizedKeys string, conductor, bell, flash bool) (*ssh.Server, error) {
+	handler := func(sess ssh.Session) (tea.Model, []tea.ProgramOption) {
+		var m tuiGnome
+		if conductor {
+			m = newTUIGnome(ctl, tuiFollower, bubbletea.MakeRenderer(sess), sess)
+		} else {
+			// Their very own gnome, silent as there's nobody here to hear it
+			var c *control
+			c = newControl(newNullGnome(beatsPerMeasure, tempoBPM, func(beat int) { c.tick(beat) }))
+			c.history = "" // theirs, not ours to log
+			c.SetPattern(beatString(beatsPerMeasure))
+			m = newTUIGnome(c, tuiRemote, bubbletea.MakeRenderer(sess), sess)
+		}
+		m.bell.on = bell
+		m.flash = flash
+
+		// They may well hang up without quitting.
+		go func() {
+			<-sess.Context().Done()
+			m.Close()
+		}()
+		return m, []tea.ProgramOption{tea.WithMouseCellMotion()}
+	}
+
+	opts := []ssh.Option{
+		wish.WithAddress(addr),
+		wish.WithHostKeyPath(hostKey),
+		wish.WithMiddleware(
+			bubbletea.Middleware(handler),
+			activeterm.Middleware(), // Bubble Tea apps usually require a PTY.
+			logging.Middleware(),
+		),
+	}
+	if authorizedKeys != "" {
+		opts = append(opts, wish.WithAuthorizedKeys(authorizedKeys))
+	}
+	return wish.NewServer(opts...)
+}
+
+// loopbackAddr returns true if addr (host:port) only listens on this machine.
+func loopbackAddr(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
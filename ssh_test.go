@@ -0,0 +1,107 @@
+//go:build !wasm
+
+package main
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	gossh "golang.org/x/crypto/ssh"
+)
+
+// sshKey returns a new key to log in with.
+func sshKey(t *testing.T) gossh.Signer {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := gossh.NewSignerFromKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signer
+}
+
+func TestServeSSHAuthorizedKeys(t *testing.T) {
+	dir := t.TempDir()
+	listed, unlisted := sshKey(t), sshKey(t)
+	authorized := filepath.Join(dir, "authorized_keys")
+	if err := os.WriteFile(authorized, gossh.MarshalAuthorizedKey(listed.PublicKey()), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := newSSHServer(l.Addr().String(), filepath.Join(dir, "host_ed25519"), authorized, false, false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go s.Serve(l)
+	defer s.Close()
+
+	dial := func(key gossh.Signer) (*gossh.Client, error) {
+		return gossh.Dial("tcp", l.Addr().String(), &gossh.ClientConfig{
+			User:            "gnome",
+			Auth:            []gossh.AuthMethod{gossh.PublicKeys(key)},
+			HostKeyCallback: gossh.InsecureIgnoreHostKey(),
+			Timeout:         5 * time.Second,
+		})
+	}
+
+	if c, err := dial(unlisted); err == nil {
+		c.Close()
+		t.Fatal("a key that isn't in authorized_keys got in")
+	}
+
+	c, err := dial(listed)
+	if err != nil {
+		t.Fatalf("a key that's in authorized_keys was refused: %s", err)
+	}
+	defer c.Close()
+
+	// And gets the TUI.
+	sess, err := c.NewSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sess.Close()
+	out, err := sess.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sess.RequestPty("xterm-256color", 40, 100, gossh.TerminalModes{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := sess.Shell(); err != nil {
+		t.Fatal(err)
+	}
+	got := make(chan []byte)
+	go func() {
+		var seen []byte
+		buf := make([]byte, 4096)
+		for !bytes.Contains(seen, []byte("quit")) {
+			n, err := out.Read(buf)
+			if err != nil {
+				break
+			}
+			seen = append(seen, buf[:n]...)
+		}
+		got <- seen
+	}()
+	select {
+	case seen := <-got:
+		if !bytes.Contains(seen, []byte("quit")) {
+			t.Errorf("the session showed %q, not the gnome", seen)
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("the session showed nothing")
+	}
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// The tap-along game has the student tap along with the gnome, for a round of so many taps,
+// and scores how close each tap is to the nearest beat.
+
+const (
+	// tapOnIt is close enough to a beat to call it on the beat.
+	tapOnIt = 25 * time.Millisecond
+	// tapStreak is close enough to keep the streak going.
+	tapStreak = 60 * time.Millisecond
+	// tapMiss is far enough off to score nothing.
+	tapMiss = 120 * time.Millisecond
+	// tapBin is how wide each bar of the histogram is.
+	tapBin = 20 * time.Millisecond
+)
+
+// tapRoundTaps is how many taps a round of the tap-along game lasts.
+var tapRoundTaps = 32
+
+// tapRound is a round of the tap-along game.
+type tapRound struct {
+	taps    int             // in the round
+	offsets []time.Duration // of each tap from its beat, early being negative
+	streak  int             // taps in a row within tapStreak
+	best    int             // the longest streak
+}
+
+// tap scores a tap at at, against the nearer of beat (when the last one was due) and the
+// one after it, a period later. It returns how far off the tap was, early being negative,
+// or false if there's no beat to tap along with: none yet, or none for so long that the
+// gnome can't be ticking.
+func (r *tapRound) tap(at, beat time.Time, period time.Duration) (time.Duration, bool) {
+	if beat.IsZero() || at.Sub(beat) > period*3/2 || r.done() {
+		return 0, false
+	}
+	off := at.Sub(beat)
+	if off > period/2 {
+		// Early for the next one
+		off -= period
+	}
+
+	r.offsets = append(r.offsets, off)
+	if off.Abs() <= tapStreak {
+		r.streak++
+		r.best = max(r.best, r.streak)
+	} else {
+		r.streak = 0
+	}
+	return off, true
+}
+
+// done returns true once the round has had all its taps.
+func (r *tapRound) done() bool {
+	return len(r.offsets) >= r.taps
+}
+
+// stop ends the round early, with the taps so far.
+func (r *tapRound) stop() {
+	r.taps = len(r.offsets)
+}
+
+// score returns the round's score out of 100: full marks for every tap right on its
+// beat, less the further off they were, down to nothing at tapMiss.
+func (r *tapRound) score() int {
+	if len(r.offsets) == 0 {
+		return 0
+	}
+	var total float64
+	for _, off := range r.offsets {
+		total += max(1-float64(off.Abs())/float64(tapMiss), 0)
+	}
+	return int(100*total/float64(len(r.offsets)) + 0.5)
+}
+
+// mean returns how early (negative) or late the taps were on average.
+func (r *tapRound) mean() time.Duration {
+	if len(r.offsets) == 0 {
+		return 0
+	}
+	var total time.Duration
+	for _, off := range r.offsets {
+		total += off
+	}
+	return total / time.Duration(len(r.offsets))
+}
+
+// histogram returns how many taps landed in each tapBin, from tapMiss early to tapMiss late.
+// Taps further off than that count in the end bins.
+func (r *tapRound) histogram() []int {
+	bins := make([]int, 2*tapMiss/tapBin)
+	for _, off := range r.offsets {
+		i := int((off + tapMiss) / tapBin)
+		if off < -tapMiss {
+			i = 0
+		}
+		bins[min(max(i, 0), len(bins)-1)]++
+	}
+	return bins
+}
+
+// summary returns the round summed up, a line at a time: the score, the tendency, the
+// best streak, and the histogram.
+func (r *tapRound) summary() []string {
+	lines := []string{
+		fmt.Sprintf("Score %d/100, over %d taps", r.score(), len(r.offsets)),
+		"On average " + tapFeedback(r.mean()),
+		fmt.Sprintf("Best streak %d", r.best),
+		"",
+	}
+	bins := r.histogram()
+	most := 1
+	for _, n := range bins {
+		most = max(most, n)
+	}
+	for i, n := range bins {
+		from := -tapMiss + time.Duration(i)*tapBin
+		label := fmt.Sprintf("%+4d to %+4dms", from.Milliseconds(), (from + tapBin).Milliseconds())
+		switch i {
+		case 0:
+			label = fmt.Sprintf("%14s", fmt.Sprintf("< %+dms", (from+tapBin).Milliseconds()))
+		case len(bins) - 1:
+			label = fmt.Sprintf("%14s", fmt.Sprintf("> %+dms", from.Milliseconds()))
+		}
+		lines = append(lines, fmt.Sprintf("%s %s %d", label, strings.Repeat("#", n*20/most), n))
+	}
+	return lines
+}
+
+// tapFeedback says how far off a tap was, e.g. "on the beat", or "34ms early".
+func tapFeedback(off time.Duration) string {
+	switch {
+	case off.Abs() <= tapOnIt:
+		return fmt.Sprintf("on the beat (%+dms)", off.Milliseconds())
+	case off < 0:
+		return fmt.Sprintf("%dms early", -off.Milliseconds())
+	}
+	return fmt.Sprintf("%dms late", off.Milliseconds())
+}
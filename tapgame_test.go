@@ -0,0 +1,83 @@
+package main
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestTapRoundTap(t *testing.T) {
+	const period = 500 * time.Millisecond // 120 BPM
+	beat := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		at   time.Duration // after beat
+		want time.Duration
+		ok   bool
+	}{
+		{0, 0, true},
+		{30 * time.Millisecond, 30 * time.Millisecond, true},
+		{period / 2, period / 2, true},
+		{period/2 + time.Millisecond, -period/2 + time.Millisecond, true},
+		{period - 20*time.Millisecond, -20 * time.Millisecond, true},
+		{period + 40*time.Millisecond, 40 * time.Millisecond, true},
+		{-10 * time.Millisecond, -10 * time.Millisecond, true},
+		{2 * period, 0, false}, // the gnome's stopped, or paused
+	}
+	for _, tt := range tests {
+		r := &tapRound{taps: 1}
+		off, ok := r.tap(beat.Add(tt.at), beat, period)
+		if ok != tt.ok || off != tt.want {
+			t.Errorf("tap %s after the beat = %s, %v, want %s, %v", tt.at, off, ok, tt.want, tt.ok)
+		}
+	}
+
+	r := &tapRound{taps: 1}
+	if _, ok := r.tap(beat, time.Time{}, period); ok {
+		t.Error("tap with no beat yet was scored")
+	}
+	r.tap(beat, beat, period)
+	if _, ok := r.tap(beat, beat, period); ok {
+		t.Error("tap after the round was done was scored")
+	}
+}
+
+func TestTapRoundScore(t *testing.T) {
+	ms := time.Millisecond
+	tests := []struct {
+		offsets []time.Duration
+		want    int
+	}{
+		{nil, 0},
+		{[]time.Duration{0, 0, 0}, 100},
+		{[]time.Duration{60 * ms, -60 * ms}, 50},
+		{[]time.Duration{0, tapMiss}, 50},
+		{[]time.Duration{tapMiss, -2 * tapMiss}, 0},
+		{[]time.Duration{30 * ms}, 75},
+	}
+	for _, tt := range tests {
+		r := &tapRound{offsets: tt.offsets}
+		if got := r.score(); got != tt.want {
+			t.Errorf("score of %v = %d, want %d", tt.offsets, got, tt.want)
+		}
+	}
+}
+
+func TestTapRoundHistogram(t *testing.T) {
+	ms := time.Millisecond
+	tests := []struct {
+		offsets []time.Duration
+		want    []int
+	}{
+		{nil, []int{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}},
+		{[]time.Duration{0, 5 * ms, -5 * ms}, []int{0, 0, 0, 0, 0, 1, 2, 0, 0, 0, 0, 0}},
+		{[]time.Duration{-tapMiss, tapMiss - ms}, []int{1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1}},
+		{[]time.Duration{-time.Second, time.Second}, []int{1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1}},
+		{[]time.Duration{-101 * ms, 41 * ms}, []int{1, 0, 0, 0, 0, 0, 0, 0, 1, 0, 0, 0}},
+	}
+	for _, tt := range tests {
+		r := &tapRound{offsets: tt.offsets}
+		if got := r.histogram(); !slices.Equal(got, tt.want) {
+			t.Errorf("histogram of %v = %v, want %v", tt.offsets, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// tempoMarking is an Italian tempo marking, and the tempos it covers, from Min up to
+// (but not including) Max. Books disagree on the edges, so these are the middle of the road.
+type tempoMarking struct {
+	Name     string
+	Min, Max float64
+	Tempo    float64 // where picking it goes
+}
+
+// tempoMarkings are the markings, slowest first, with no gaps.
+var tempoMarkings = []tempoMarking{
+	{"Larghissimo", 0, 25, 20},
+	{"Grave", 25, 45, 35},
+	{"Largo", 45, 60, 50},
+	{"Larghetto", 60, 66, 63},
+	{"Adagio", 66, 76, 70},
+	{"Andante", 76, 108, 92},
+	{"Moderato", 108, 120, 114},
+	{"Allegro", 120, 156, 138},
+	{"Vivace", 156, 176, 166},
+	{"Presto", 176, 200, 184},
+	{"Prestissimo", 200, 1e9, 208},
+}
+
+// String is the name, and the tempos, e.g. "Andante (76-108)".
+func (m tempoMarking) String() string {
+	if m.Max >= 1e9 {
+		return fmt.Sprintf("%s (%s+)", m.Name, fmtTempo(m.Min))
+	}
+	return fmt.Sprintf("%s (%s-%s)", m.Name, fmtTempo(m.Min), fmtTempo(m.Max))
+}
+
+// markingFor returns the name of the marking bpm falls under.
+func markingFor(bpm float64) string {
+	for _, m := range tempoMarkings {
+		if bpm < m.Max {
+			return m.Name
+		}
+	}
+	return tempoMarkings[len(tempoMarkings)-1].Name
+}
+
+// findMarking returns the marking called name, whatever the case.
+func findMarking(name string) (tempoMarking, error) {
+	for _, m := range tempoMarkings {
+		if strings.EqualFold(m.Name, strings.TrimSpace(name)) {
+			return m, nil
+		}
+	}
+	return tempoMarking{}, fmt.Errorf("unknown tempo marking %q", name)
+}
+
+// tempoStyle is a dance or genre, and the tempo, signature, and hit pattern to play it.
+type tempoStyle struct {
+	Name      string
+	Tempo     float64
+	Signature string
+	Pattern   string
+}
+
+// String is the name, and what it'll set, e.g. "Waltz: 3/4 @ 90 bpm, 1>23".
+func (s tempoStyle) String() string {
+	return fmt.Sprintf("%s: %s @ %s bpm, %s", s.Name, s.Signature, fmtTempo(s.Tempo), s.Pattern)
+}
+
+// tempoStyles is the style library, in no particular order but the alphabet's.
+var tempoStyles = []tempoStyle{
+	{"Blues shuffle", 90, "4/4", "12>34>"},
+	{"Bossa nova", 130, "4/4", "1>234"},
+	{"Cha-cha", 120, "4/4", "1>234"},
+	{"Disco", 120, "4/4", "1>234"},
+	{"Foxtrot", 120, "4/4", "1>234"},
+	{"Funk", 100, "4/4", "12>34>"},
+	{"Hip hop", 90, "4/4", "12>34>"},
+	{"March", 120, "2/4", "1>2"},
+	{"Minuet", 110, "3/4", "1>23"},
+	{"Polka", 120, "2/4", "1>2"},
+	{"Reel", 112, "2/2", "1>2"},
+	{"Reggae", 75, "4/4", "123>4"},
+	{"Rock", 120, "4/4", "12>34>"},
+	{"Rumba", 100, "4/4", "1>234"},
+	{"Samba", 100, "2/4", "12>"},
+	{"Swing", 140, "4/4", "12>34>"},
+	{"Tango", 120, "4/4", "1>234"},
+	{"Viennese waltz", 180, "3/4", "1>23"},
+	{"Waltz", 90, "3/4", "1>23"},
+}
+
+// searchStyles returns the styles with query in their name, whatever the case, or all
+// of them if query is empty.
+func searchStyles(query string) []tempoStyle {
+	query = strings.ToLower(strings.TrimSpace(query))
+	var found []tempoStyle
+	for _, s := range tempoStyles {
+		if strings.Contains(strings.ToLower(s.Name), query) {
+			found = append(found, s)
+		}
+	}
+	return found
+}
+
+// findStyle returns the style called name, whatever the case, or the only one with
+// name in its name.
+func findStyle(name string) (tempoStyle, error) {
+	found := searchStyles(name)
+	for _, s := range found {
+		if strings.EqualFold(s.Name, strings.TrimSpace(name)) {
+			return s, nil
+		}
+	}
+	if len(found) == 1 {
+		return found[0], nil
+	}
+	return tempoStyle{}, fmt.Errorf("unknown style %q", name)
+}
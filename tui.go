@@ -4,14 +4,19 @@ package main
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"runtime/debug"
+	"slices"
 	"strings"
+	"sync"
 	"time"
 
 	"fyne.io/fyne/v2/app"
 	"github.com/charmbracelet/bubbles/help"
 	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/cognusion/go-gnome"
@@ -25,17 +30,75 @@ func init() {
 	// it gets attached IFF !wasm to keep all of this
 	// from the WASM build
 	runTUIfunc = runTUI
+	// and the GUI takes its keys from the TUI config too
+	loadTUIConfigfunc = loadTUIConfig
 
 	pflag.BoolVarP(&terminalUI, "terminal", "t", terminalUIDefault, "Use the TUI is used instead of the GUI?")
 	pflag.StringVar(&startSound, "sound", "Woodblock", "Starting sound.")
-	pflag.Int32Var(&tempoBPM, "tempo", 60, "Tempo BPM to start with (TUI and GUI)")
-	pflag.Int32Var(&tempoDelta, "delta", 10, "BPM steps when doing up or down in tempo (TUI and GUI)")
+	pflag.Float64Var(&tempoBPM, "tempo", 60, "Tempo BPM to start with, to a tenth, e.g. 92.5 (TUI and GUI)")
+	pflag.Float64Var(&tempoDelta, "delta", 10, "BPM steps when doing up or down in tempo (TUI and GUI)")
+	pflag.Float64Var(&tempoMin, "min-tempo", tempoMin, "Slowest tempo BPM allowed (TUI and GUI)")
+	pflag.Float64Var(&tempoMax, "max-tempo", tempoMax, "Fastest tempo BPM allowed (TUI and GUI)")
 	pflag.Int32Var(&beatsPerMeasure, "beats", 4, "Beats-per-measure to start with (TUI and GUI)")
+	pflag.IntVar(&countInBars, "count-in", 0, "Bars to count in before the first downbeat, on every start (TUI and GUI)")
+	pflag.IntVar(&countInBeats, "count-in-beats", 0, "Only click the last this many beats of the count-in, e.g. 2 for \"3, 4\" (TUI and GUI)")
+	pflag.StringVar(&countInSound, "count-in-sound", countInSound, "Sound for the count-in (TUI and GUI)")
+	pflag.StringVar(&accentSound, "accent-sound", accentSound, "Sound for the accented beats of the hit pattern (TUI and GUI)")
+	pflag.IntVar(&gapPlay, "gap-play", 0, "Gap trainer: click for this many bars, then go silent for --gap-silent bars, and so on (TUI and GUI)")
+	pflag.IntVar(&gapSilent, "gap-silent", 0, "Gap trainer: bars to go silent for, after --gap-play bars (TUI and GUI)")
+	pflag.Float64Var(&gapRandom, "gap-random", 0, "Gap trainer: percent of the beats to drop at random (TUI and GUI)")
+	pflag.DurationVar(&practiceFor, "practice", 0, "Practice timer: stop after playing this long, e.g. 10m (TUI and GUI)")
+	pflag.IntVar(&practiceBars, "practice-bars", 0, "Practice timer: stop after this many bars, instead (TUI and GUI)")
+	pflag.DurationVar(&practiceBreak, "practice-break", 0, "Practice timer: take a break this long when it stops, then start again, e.g. 2m (TUI and GUI)")
+	pflag.IntVar(&tapRoundTaps, "tap-round", tapRoundTaps, "Taps a round of the tap-along game lasts (TUI and GUI)")
+	historyOn := pflag.Bool("history", true, "Log every practice session, for the history command and the GUI's History (TUI and GUI)")
+	pflag.StringVar(&historyPath, "history-path", "", "Practice log path (default $XDG_CONFIG_HOME/metrognome/history.jsonl)")
+	pflag.StringVar(&voiceName, "voice", "", "Count out loud with this voice: a bundled one, one in $XDG_CONFIG_HOME/metrognome/voices, or a directory (TUI and GUI)")
+	pflag.BoolVar(&voiceOnly, "voice-only", false, "Count out loud instead of clicking, rather than over the click")
+	pflag.StringVar(&voiceSub, "voice-subdivide", "", "Count the subdivisions too: and (1 and 2 and), or e-and-a (1 e and a)")
+	pflag.Float64SliceVar(&tempoPresets, "presets", tempoPresets, "Tempo presets, up to 9, for keys 1-9 (TUI and GUI)")
+	pflag.StringVar(&tuiConfigPath, "tui-config", "", "TUI (and GUI) key bindings, and TUI colors file (default $XDG_CONFIG_HOME/metrognome/tui.json, if it exists)")
+	pflag.StringVar(&clickModes, "click", "audio", "How to click: any of audio, bell (terminal bell), flash (the background), e.g. bell,flash (bell and flash are TUI only)")
+	pflag.StringVar(&listenAddr, "listen", "", "Address (e.g. localhost:8080) to serve the HTTP control API on (TUI and GUI)")
+	pflag.BoolVar(&socketOn, "socket", false, "Accept `metrognome ctl` commands on a Unix socket (TUI and GUI)")
+	pflag.StringVar(&socketPath, "socket-path", "", "Unix socket path for --socket and ctl (default $XDG_RUNTIME_DIR/metrognome-UID.sock)")
+	pflag.BoolVar(&mprisOn, "mpris", false, "Register as an MPRIS player, for media keys and widgets (Linux only, TUI and GUI)")
+	pflag.StringVar(&followURL, "follow", "", "Follow the conductor at this address (its --listen), clicking in sync with it (TUI and GUI)")
+	pflag.BoolVar(&headless, "headless", false, "Use neither the TUI nor the GUI, only the control surfaces")
+	pflag.StringVar(&controlMode, "control", "", "Control surface for --headless: stdio (commands in, JSON-lines events out)")
 	version := pflag.BoolP("version", "v", false, "Display version information and exit")
 
-	pflag.CommandLine.SortFlags = false // we want them in the order we put them
+	pflag.CommandLine.SortFlags = false      // we want them in the order we put them
+	pflag.CommandLine.SetInterspersed(false) // so `ctl tempo -5` isn't a flag
 	pflag.Parse()
 
+	if socketPath == "" {
+		socketPath = defaultSocketPath()
+	}
+	if historyPath == "" {
+		historyPath = defaultHistoryPath()
+	}
+
+	// Subcommands
+	if args := pflag.Args(); len(args) > 0 {
+		switch args[0] {
+		case "ctl":
+			os.Exit(runCtl(socketPath, args[1:]))
+		case "serve-ssh":
+			sanityCheck()
+			os.Exit(runServeSSH(args[1:]))
+		case "history":
+			os.Exit(runHistory(historyPath, args[1:]))
+		default:
+			fmt.Printf("Unknown command '%s'. Must be one of: ctl, serve-ssh, history\n", args[0])
+			os.Exit(1)
+		}
+	}
+
+	if !*historyOn {
+		historyPath = ""
+	}
+
 	if *version {
 		var (
 			mgv string
@@ -76,160 +139,223 @@ func init() {
 	}
 }
 
-type keyMap struct {
-	Up    key.Binding
-	Down  key.Binding
-	Pause key.Binding
-	Mute  key.Binding
-	Drift key.Binding
-	Pan   key.Binding
-	Help  key.Binding
-	Quit  key.Binding
-}
-
-func (k keyMap) ShortHelp() []key.Binding {
-	return []key.Binding{k.Help, k.Quit}
-}
-
-func (k keyMap) FullHelp() [][]key.Binding {
-	// trying to keep each column <= 3 lines
-	return [][]key.Binding{
-		{k.Up, k.Down, k.Pan},      // first column
-		{k.Pause, k.Mute, k.Drift}, // second column
-		{k.Help, k.Quit},           // third column
+func runTUI(g clicker) {
+	if err := loadTUIConfig(tuiConfigPath); err != nil {
+		fmt.Printf("Could not load the TUI config: %s\n", err)
+		os.Exit(1)
 	}
-}
-
-var keys = keyMap{
-	Up: key.NewBinding(
-		key.WithKeys("up", "a"),
-		key.WithHelp("↑/a", "tempo up"),
-	),
-	Down: key.NewBinding(
-		key.WithKeys("down", "z"),
-		key.WithHelp("↓/z", "tempo down"),
-	),
-	Pause: key.NewBinding(
-		key.WithKeys("p"),
-		key.WithHelp("p", "Pause/Resume"),
-	),
-	Pan: key.NewBinding(
-		key.WithKeys("r"),
-		key.WithHelp("r", "Pan/Unpan"),
-	),
-	Mute: key.NewBinding(
-		key.WithKeys("m"),
-		key.WithHelp("m", "Mute/Unmute"),
-	),
-	Drift: key.NewBinding(
-		key.WithKeys("d"),
-		key.WithHelp("d", "Display drift"),
-	),
-	Help: key.NewBinding(
-		key.WithKeys("?"),
-		key.WithHelp("?", "toggle help"),
-	),
-	Quit: key.NewBinding(
-		key.WithKeys("q", "esc", "ctrl+c"),
-		key.WithHelp("q", "quit"),
-	),
-}
-
-func runTUI(g *gnome.Gnome) {
-	var (
-		err       error
-		tf        func(int)
-		buff      *recyclable.Buffer
-		tickChan  = make(chan string, 1)
-		startTime time.Time
-		lastDrift time.Duration
-		its       int64
-		//statChan = make(chan string, 1)
-	)
-
-	// Every time there is a tick, print a star.
-	tf = func(beat int) {
-		its++
-		ntime := startTime.Add(g.TS.TempoToDuration() * time.Duration(its))
-		lastDrift = time.Since(ntime)
-		if beat == int(g.TS.Beats.Load()) {
-			tickChan <- fmt.Sprintf("%d|", beat)
-		} else {
-			tickChan <- fmt.Sprintf("%d", beat)
-		}
-	}
-
-	rt := func() {
-		its = 0
-		startTime = time.Now()
-	}
-
-	// Get a buffer and pass it on
-	buff = gnome.RPool.Get()
-	buff.Reset(*sounds[startSound])
 
 	// g is always nil, but this makes the compiler happy since we are
 	// passing in the nil reference to reuse.
 	if g == nil {
-		g, err = gnome.NewGnomeFromBuffer(buff, gnome.NewTimeSignature(beatsPerMeasure, 4, tempoBPM), tf)
-		if err != nil {
-			panic(err)
-		}
+		g = newGnome(func(beat int) { ctl.tick(beat) })
 		// defer g.Close()
 	}
+	mg = g // so the control surfaces can find it
+	ctl = newControl(g)
+
+	// The hit pattern isn't set until someone sets it, and without it nothing's filtered
+	ctl.SetPattern(beatString(beatsPerMeasure))
 
+	startControl()
+	defer stopControl() // the socket file, and all
+
+	m := newTUIGnome(ctl, tuiLocal, lipgloss.DefaultRenderer(), os.Stdout)
+	m.bell.on = clickBell
+	m.flash = clickFlash
+	tg := tea.NewProgram(m, tea.WithMouseCellMotion())
+	tg.Run()
+
+}
+
+// tuiMode is who a tuiGnome is for.
+type tuiMode int
+
+const (
+	tuiLocal    tuiMode = iota // the local terminal, with the gnome's own sound
+	tuiRemote                  // a remote session, with its own (silent) gnome
+	tuiFollower                // a remote session, following someone else's gnome
+)
+
+// newTUIGnome returns a tuiGnome for c, in mode, drawing with r. If the bell is on,
+// it's rung on out.
+func newTUIGnome(c *control, mode tuiMode, r *lipgloss.Renderer, out io.Writer) tuiGnome {
 	b := gnome.RPool.Get()
-	defer b.Close()
 	b.Reset(make([]byte, 0))
 
-	tg := tea.NewProgram(tuiGnome{
-		Gnome:      g,
+	h := help.New()
+	colors.helpStyles(&h, r)
+
+	g := tuiGnome{
+		ctl:        c,
+		mode:       mode,
+		events:     c.Subscribe(),
 		Buffer:     b,
-		tickChan:   tickChan,
+		clock:      &tuiClock{},
+		bell:       &tuiBell{out: out},
 		keys:       keys,
-		help:       help.New(),
-		inputStyle: lipgloss.NewStyle().Foreground(lipgloss.Color("#FF75B7")),
-		startTime:  &startTime,
-		lastDrift:  &lastDrift,
-		resetTime:  rt,
+		help:       h,
+		input:      newTUIInput(),
+		picker:     newPicker(),
+		inputStyle: r.NewStyle().Foreground(lipgloss.Color(colors.Accent)),
+		flashStyle: r.NewStyle().Background(lipgloss.Color(colors.Flash)),
+		downStyle:  r.NewStyle().Background(lipgloss.Color(colors.Downbeat)),
+	}
+
+	// Remote sessions can't hear the gnome, and the audio they'd be
+	// fiddling with isn't theirs, so they ring and flash instead.
+	if mode != tuiLocal {
+		g.keys.Mute.SetEnabled(false)
+		g.keys.Pan.SetEnabled(false)
+		g.keys.Sound.SetEnabled(false)
+		g.keys.History.SetEnabled(false) // there's no log of theirs
+	}
+	// Followers are just along for the ride.
+	if mode == tuiFollower {
+		g.keys.Up.SetEnabled(false)
+		g.keys.Down.SetEnabled(false)
+		g.keys.Pause.SetEnabled(false)
+		g.keys.Tempo.SetEnabled(false)
+		g.keys.Tap.SetEnabled(false)
+		g.keys.Preset.SetEnabled(false)
+		g.keys.Marking.SetEnabled(false)
+		g.keys.Style.SetEnabled(false)
+		g.keys.Stop.SetEnabled(false)
+		g.keys.Restart.SetEnabled(false)
+		g.keys.Signature.SetEnabled(false)
+		g.keys.Pattern.SetEnabled(false)
+		g.keys.Gap.SetEnabled(false)
+		g.keys.Practice.SetEnabled(false)
+	}
+
+	// Cleaning up happens once, whoever gets there first (see runServeSSH).
+	g.cleanup = sync.OnceFunc(func() {
+		c.Unsubscribe(g.events)
+		if mode != tuiFollower {
+			c.Stop()
+			c.g.Close()
+		}
+		b.Close()
 	})
-	tg.Run()
 
+	return g
+}
+
+type tickMsg controlEvent
+
+type stateMsg controlEvent
+
+// practiceMsg is the practice timer going off.
+type practiceMsg controlEvent
+
+// clockMsg is time to redraw the practice timer, which counts down between beats too.
+type clockMsg struct{}
+
+// nextClock has a clockMsg sent in a second.
+func nextClock() tea.Msg {
+	time.Sleep(time.Second)
+	return clockMsg{}
 }
 
-type tickMsg string
+// unflashMsg ends the flash for a beat.
+type unflashMsg int
+
+// tuiClock keeps track of where the ticks should land, to measure drift against.
+type tuiClock struct {
+	start time.Time
+	its   int64
+	drift time.Duration
+}
+
+// reset starts the clock over from now.
+func (c *tuiClock) reset() {
+	c.start = time.Now()
+	c.its = 0
+}
+
+// tock counts a tick that landed at when, d after the last.
+func (c *tuiClock) tock(when time.Time, d time.Duration) {
+	c.its++
+	c.drift = when.Sub(c.start.Add(d * time.Duration(c.its)))
+}
+
+// tuiBell rings the terminal bell on out, if it's on.
+type tuiBell struct {
+	on  bool
+	out io.Writer
+}
 
 type tuiGnome struct {
-	Gnome        *gnome.Gnome
+	ctl          *control
+	mode         tuiMode
+	events       chan controlEvent
 	Buffer       *recyclable.Buffer
-	startTime    *time.Time
-	resetTime    func()
-	tickChan     chan string
+	cleanup      func()
+	clock        *tuiClock
+	bell         *tuiBell
+	flash        bool      // flash the background every beat?
+	flashing     int       // the beat we're flashing for, if any
+	beat         int       // the last beat
+	countIn      int       // the last beat's place in the count-in, if it was in it
+	game         *tapRound // the tap-along game's round, if there's one going (see tui_tapgame.go)
+	history      []string  // the practice log, while it's showing (see tui_history.go)
+	silent       bool      // the last beat was in a gap
+	beatAt       time.Time // when it was
+	view         tuiView   // how the beat is shown (see tui_anim.go)
+	frameGen     int       // which animation is running, if the view is animated
 	lastMessage  string
-	lastDrift    *time.Duration
 	displayDrift bool
 	width        int
 	height       int
 	keys         keyMap
 	help         help.Model
+	editing      tuiEdit         // what's being edited, if anything (see tui_edit.go)
+	editErr      string          // why the last try at it didn't take
+	input        textinput.Model // for editing everything but the sound
+	picker       list.Model      // for picking the sound, tempo marking, or style
 	inputStyle   lipgloss.Style
+	flashStyle   lipgloss.Style
+	downStyle    lipgloss.Style // flashing the downbeat
 }
 
 func (g tuiGnome) Init() tea.Cmd {
-	*g.startTime = time.Now()
-	g.Gnome.Start()
+	g.clock.reset()
+	if g.mode != tuiFollower {
+		g.ctl.Start()
+	}
 	g.lastMessage = "RUNNING"
-	return g.tick
+	if audioErr != nil {
+		g.lastMessage = "RUNNING (NO AUDIO)"
+	}
+	return tea.Batch(g.tick, nextClock)
 }
 
 func (g tuiGnome) Close() {
-	g.Gnome.Stop()
-	g.Gnome.Close()
+	g.cleanup()
 }
 
 func (g tuiGnome) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if g.editing != editNone {
+		switch msg.(type) {
+		case tickMsg, stateMsg, practiceMsg, clockMsg, unflashMsg, frameMsg, tea.WindowSizeMsg:
+			// Keep the beat while they're at it
+		default:
+			return g.updateEdit(msg)
+		}
+	}
+
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		if g.history != nil {
+			if g, ok := g.historyKey(msg); ok {
+				return g, nil
+			}
+		}
+		if g.game != nil {
+			if g, ok := g.gameKey(msg); ok {
+				return g, nil
+			}
+		}
 		switch {
 		case key.Matches(msg, g.keys.Quit):
 			// Quit
@@ -242,33 +368,96 @@ func (g tuiGnome) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			g.help.ShowAll = !g.help.ShowAll
 
 		case key.Matches(msg, g.keys.Pause):
-			// Pause
-			g.Gnome.Pause()
-			g.resetTime()
+			// Start, Pause, or Resume
+			g.lastMessage = g.playPause()
+			return g, nil
+
+		case key.Matches(msg, g.keys.Tap):
+			// Tap tempo
+			bpm, err := g.ctl.Tap()
+			switch {
+			case err != nil:
+				g.lastMessage = strings.ToUpper(err.Error())
+			case bpm == 0:
+				g.lastMessage = "TAP"
+			default:
+				g.lastMessage = "TAP " + fmtTempo(bpm)
+			}
+			return g, nil
+
+		case key.Matches(msg, g.keys.Preset):
+			// Preset, by which of its keys it is, the first being preset 1
+			n := slices.Index(g.keys.Preset.Keys(), msg.String()) + 1
+			if n < 1 || n > len(tempoPresets) {
+				g.lastMessage = fmt.Sprintf("NO PRESET %d", n)
+				return g, nil
+			}
+			g.lastMessage = errOr(g.ctl.Preset(n), fmt.Sprintf("PRESET %d", n))
 			return g, nil
 
+		case key.Matches(msg, g.keys.Stop):
+			// Stop
+			if err := g.ctl.Stop(); err != nil {
+				g.lastMessage = strings.ToUpper(err.Error())
+				return g, nil
+			}
+			g.lastMessage = "STOPPED"
+			return g, nil
+
+		case key.Matches(msg, g.keys.Restart):
+			// Restart, from the top
+			if err := g.ctl.Restart(); err != nil {
+				g.lastMessage = strings.ToUpper(err.Error())
+				return g, nil
+			}
+			g.clock.reset()
+			g.Buffer.Reset([]byte{})
+			g.lastMessage = "RESTARTED"
+			return g, nil
+
+		case key.Matches(msg, g.keys.Tempo):
+			return g.startEdit(editTempo)
+
+		case key.Matches(msg, g.keys.Signature):
+			return g.startEdit(editSignature)
+
+		case key.Matches(msg, g.keys.Pattern):
+			return g.startEdit(editPattern)
+
+		case key.Matches(msg, g.keys.Sound):
+			return g.startEdit(editSound)
+
+		case key.Matches(msg, g.keys.Marking):
+			return g.startEdit(editMarking)
+
+		case key.Matches(msg, g.keys.Style):
+			return g.startEdit(editStyle)
+
+		case key.Matches(msg, g.keys.Gap):
+			return g.startEdit(editGap)
+
+		case key.Matches(msg, g.keys.Practice):
+			return g.startEdit(editPractice)
+
+		case key.Matches(msg, g.keys.Game):
+			return g.startGame(), nil
+
+		case key.Matches(msg, g.keys.History):
+			return g.showHistory(), nil
+
 		case key.Matches(msg, g.keys.Up):
 			// Up
-			new := g.Gnome.TS.Tempo.Add(tempoDelta)
-			g.Gnome.Change(new)
-			g.lastMessage = fmt.Sprintf("TEMPO +%d", tempoDelta)
+			g.lastMessage = errOr(g.ctl.NudgeTempo(tempoDelta), "TEMPO "+fmtTempoDelta(tempoDelta))
 			return g, nil
 
 		case key.Matches(msg, g.keys.Down):
 			// Down
-			new := g.Gnome.TS.Tempo.Add(-1 * tempoDelta)
-			if new <= 0 {
-				// Safety
-				g.Gnome.TS.Tempo.Add(tempoDelta)
-			} else {
-				g.Gnome.Change(new)
-			}
-			g.lastMessage = fmt.Sprintf("TEMPO -%d", tempoDelta)
+			g.lastMessage = errOr(g.ctl.NudgeTempo(-1*tempoDelta), "TEMPO "+fmtTempoDelta(-tempoDelta))
 			return g, nil
 
 		case key.Matches(msg, g.keys.Mute):
 			// Mute
-			g.Gnome.Mute()
+			g.ctl.Mute()
 			g.lastMessage = "MUTE"
 
 		case key.Matches(msg, g.keys.Drift):
@@ -277,25 +466,97 @@ func (g tuiGnome) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		case key.Matches(msg, g.keys.Pan):
 			// Pan
-			g.Gnome.Pan()
+			g.ctl.Pan()
 			g.lastMessage = "PAN"
+
+		case key.Matches(msg, g.keys.Bell):
+			// Bell
+			g.bell.on = !g.bell.on
+			g.lastMessage = "BELL"
+
+		case key.Matches(msg, g.keys.View):
+			// View, the next one
+			g.view = (g.view + 1) % viewCount
+			g.lastMessage = g.view.String()
+			if g.view.animated() {
+				g.frameGen++
+				return g, frame(g.frameGen)
+			}
+
+		case key.Matches(msg, g.keys.Flash):
+			// Flash
+			g.flash = !g.flash
+			g.lastMessage = "FLASH"
 		}
 
+	case tea.MouseMsg:
+		return g.mouse(msg), nil
+
 	case tea.WindowSizeMsg:
 		g.width = msg.Width
 		g.height = msg.Height
+		g.picker.SetSize(msg.Width, max(msg.Height-2, 5))
 		g.lastMessage = fmt.Sprintf("%+v", msg)
 		return g, nil
 
 	case tickMsg:
+		g.clock.tock(msg.Time, g.ctl.Period())
+		g.beat = msg.Beat
+		g.beatAt = msg.Time
+		g.countIn = msg.CountIn
+		g.silent = msg.Silent
+
+		beat := fmt.Sprintf("%d", msg.Beat)
+		if msg.CountIn > 0 {
+			beat = "(" + beat + ")"
+		}
+		if msg.Beat == int(g.ctl.ts.Beats.Load()) {
+			beat += "|"
+		}
 		if g.Buffer.Len() >= g.width {
 			// overlong
-			g.Buffer.Reset([]byte(msg))
+			g.Buffer.Reset([]byte(beat))
 		} else {
 			// ++
-			g.Buffer.Write([]byte(msg))
+			g.Buffer.Write([]byte(beat))
 		}
+		if msg.Unheard {
+			// Nothing to ring or flash for
+			return g, g.tick
+		}
+		if !g.flash && g.view != viewBig {
+			return g, tea.Batch(g.tick, g.ring)
+		}
+
+		// Flash (the background, or the big downbeat) for a bit, but never into the next beat
+		g.flashing = msg.Beat
+		d := min(100*time.Millisecond, g.ctl.Period()/2)
+		return g, tea.Batch(g.tick, g.ring, tea.Tick(d, func(time.Time) tea.Msg { return unflashMsg(msg.Beat) }))
+
+	case frameMsg:
+		if int(msg) != g.frameGen || !g.view.animated() {
+			// An old one
+			return g, nil
+		}
+		return g, frame(g.frameGen)
+
+	case unflashMsg:
+		if g.flashing == int(msg) {
+			g.flashing = 0
+		}
+		return g, nil
+
+	case stateMsg:
+		// Someone else changed something, so just redraw
 		return g, g.tick
+
+	case practiceMsg:
+		g.lastMessage = "PRACTICE DONE"
+		return g, tea.Batch(g.tick, g.ring)
+
+	case clockMsg:
+		// Just redraw
+		return g, nextClock
 	}
 	return g, nil
 }
@@ -303,21 +564,98 @@ func (g tuiGnome) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 func (g tuiGnome) View() string {
 	var extra string
 	if g.displayDrift {
-		extra = fmt.Sprintf(" - Drift: %s", g.lastDrift.String())
+		extra = fmt.Sprintf(" - Drift: %s", g.clock.drift.String())
 	}
 
-	var status = fmt.Sprintf("%s - %s%s\n%s\n", g.Gnome.TS.String(), g.lastMessage, extra, wordwrap.String(g.Buffer.String(), g.width))
+	s := g.ctl.Status()
+	if s.PracticeLeft > 0 && s.Running {
+		extra += " - " + fmtClock(time.Duration(s.PracticeLeft)*time.Second) + " LEFT"
+	}
+	var status = fmt.Sprintf("%s - %s%s\n", s, g.lastMessage, extra)
 
-	if g.Gnome.IsPaused() {
+	if s.BreakLeft > 0 {
+		status = "BREAK " + fmtClock(time.Duration(s.BreakLeft)*time.Second) + " - " + status
+	}
+	if g.countIn > 0 && s.Running {
+		status = "COUNT-IN - " + status
+	}
+	if g.silent && s.Running {
+		status = "GAP - " + status
+	}
+	if g.ctl.g.IsPaused() {
 		status = "PAUSED - " + status
 	}
 
+	if g.editing != editNone {
+		return g.editView(status)
+	}
+	status = g.buttonsView() + status
+
 	helpView := g.help.View(g.keys)
-	height := 5 - strings.Count(status, "\n") - strings.Count(helpView, "\n")
 
-	return "\n" + status + strings.Repeat("\n", height) + helpView
+	// Everything but the scroll fills what's between the status and the help
+	height := max(g.height-2-strings.Count(status, "\n")-strings.Count(helpView, "\n"), 1)
+
+	var view string
+	switch {
+	case g.game != nil && g.game.done():
+		view = "\n" + status + g.gameView() + helpView
+	case g.history != nil:
+		view = "\n" + status + g.historyView(height) + helpView
+	case g.view == viewBig:
+		var beat string
+		if g.beat > 0 {
+			beat = bigNumber(g.beat, g.width, height)
+		}
+		if (g.flashing == 1 || g.accented(g.flashing)) && !g.flash {
+			// The background isn't flashing, so the downbeat does instead.
+			beat = g.inputStyle.Render(beat)
+		}
+		view = "\n" + status + lipgloss.Place(g.width, height, lipgloss.Center, lipgloss.Center, beat) + "\n" + helpView
+	case g.view == viewPendulum:
+		view = "\n" + status + g.pendulumView(g.width, height) + "\n" + helpView
+	case g.view == viewConduct:
+		view = "\n" + status + g.conductView(g.width, height) + "\n" + helpView
+	default:
+		status += wordwrap.String(g.Buffer.String(), g.width) + "\n"
+		height := max(5-strings.Count(status, "\n")-strings.Count(helpView, "\n"), 0)
+		view = "\n" + status + strings.Repeat("\n", height) + helpView
+	}
+
+	switch {
+	case g.flashing == 0:
+		return view
+	case g.flashing == 1 || g.accented(g.flashing):
+		return g.downStyle.Width(g.width).Render(view)
+	}
+	return g.flashStyle.Width(g.width).Render(view)
 }
 
+// accented returns true if beat is accented in the hit pattern.
+func (g tuiGnome) accented(beat int) bool {
+	states := patternStates(g.ctl.Status().Pattern, g.ctl.ts.Beats.Load())
+	return beat >= 1 && beat <= len(states) && states[beat-1] == beatAccent
+}
+
+// tick waits for the next beat, or state change.
 func (g tuiGnome) tick() tea.Msg {
-	return tickMsg(<-g.tickChan)
+	for e := range g.events {
+		switch e.Type {
+		case "beat":
+			return tickMsg(e)
+		case "state":
+			return stateMsg(e)
+		case "practice":
+			return practiceMsg(e)
+		}
+	}
+	return nil // unsubscribed
+}
+
+// ring rings the bell, if it's on.
+func (g tuiGnome) ring() tea.Msg {
+	if g.bell.on {
+		g.bell.out.Write([]byte("\a"))
+	}
+	return nil
 }
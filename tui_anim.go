@@ -0,0 +1,195 @@
+//go:build !wasm
+
+package main
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// tuiView is how the TUI shows the beat.
+type tuiView int
+
+const (
+	viewScroll   tuiView = iota // the beats so far, small
+	viewBig                     // the beat, big (see tui_big.go)
+	viewPendulum                // a metronome's pendulum
+	viewConduct                 // a conductor's baton
+	viewCount                   // how many views there are
+)
+
+// String is the name of the view, for the status line.
+func (v tuiView) String() string {
+	switch v {
+	case viewBig:
+		return "BIG"
+	case viewPendulum:
+		return "PENDULUM"
+	case viewConduct:
+		return "CONDUCTOR"
+	}
+	return "SCROLL"
+}
+
+// animated returns true if the view moves between beats.
+func (v tuiView) animated() bool {
+	return v == viewPendulum || v == viewConduct
+}
+
+// frameEvery is how often animated views are redrawn.
+const frameEvery = time.Second / 30
+
+// frameMsg is time to redraw an animated view. Frames from an older animation
+// (see tuiGnome.frameGen) are dropped.
+type frameMsg int
+
+// frame returns a command for the next frame of animation gen.
+func frame(gen int) tea.Cmd {
+	return tea.Tick(frameEvery, func(time.Time) tea.Msg { return frameMsg(gen) })
+}
+
+// phase returns how far we are from the last beat to the next, from 0 to 1.
+// It holds at 1 if the next beat is late, e.g. while paused.
+func (g tuiGnome) phase() float64 {
+	if g.beatAt.IsZero() {
+		return 0
+	}
+	p := float64(time.Since(g.beatAt)) / float64(g.ctl.Period())
+	return min(max(p, 0), 1)
+}
+
+// tuiCanvas is a grid of cells to draw on.
+type tuiCanvas [][]rune
+
+// newCanvas returns a blank canvas, width x height.
+func newCanvas(width, height int) tuiCanvas {
+	c := make(tuiCanvas, max(height, 0))
+	for y := range c {
+		c[y] = []rune(strings.Repeat(" ", max(width, 0)))
+	}
+	return c
+}
+
+// set puts r at x, y, if that's on the canvas.
+func (c tuiCanvas) set(x, y int, r rune) {
+	if y >= 0 && y < len(c) && x >= 0 && x < len(c[y]) {
+		c[y][x] = r
+	}
+}
+
+// write puts s at x, y, going right.
+func (c tuiCanvas) write(x, y int, s string) {
+	for i, r := range []rune(s) {
+		c.set(x+i, y, r)
+	}
+}
+
+func (c tuiCanvas) String() string {
+	lines := make([]string, len(c))
+	for y := range c {
+		lines[y] = string(c[y])
+	}
+	return strings.Join(lines, "\n")
+}
+
+// pendulumView draws a metronome's pendulum, width x height, swinging from one side
+// on one beat to the other on the next, landing on the beat.
+func (g tuiGnome) pendulumView(width, height int) string {
+	c := newCanvas(width, height)
+	if height < 3 || width < 5 {
+		return c.String()
+	}
+
+	// Odd beats swing left to right, even beats back. Easing like cosine
+	// makes it slow down into the ends, as a real one does.
+	x := -math.Cos(math.Pi * g.phase())
+	if g.beat%2 == 0 {
+		x = -x
+	}
+
+	const maxAngle = math.Pi / 6
+	var (
+		angle  = x * maxAngle
+		pivotX = width / 2
+		pivotY = height - 1
+		length = float64(height - 2)
+	)
+
+	// Where it lands, at either end
+	for _, end := range []float64{-maxAngle, maxAngle} {
+		c.set(pivotX+int(math.Round(2*length*math.Sin(end))), pivotY-int(math.Round(length*math.Cos(end))), '┬')
+	}
+
+	// Cells are about twice as tall as they are wide, so x is doubled.
+	steps := int(length) * 2
+	for i := 1; i < steps; i++ {
+		r := length * float64(i) / float64(steps)
+		c.set(pivotX+int(math.Round(2*r*math.Sin(angle))), pivotY-int(math.Round(r*math.Cos(angle))), '·')
+	}
+	c.set(pivotX+int(math.Round(2*length*math.Sin(angle))), pivotY-int(math.Round(length*math.Cos(angle))), '●')
+	c.set(pivotX, pivotY, '▲')
+	return c.String()
+}
+
+// conductPoints are where each beat lands in the conducting patterns, x from -1
+// (left) to 1 (right), y from 0 (the bottom, where beats land) to 1.
+var conductPoints = map[int][][2]float64{
+	1: {{0, 0}},
+	2: {{0, 0}, {0.4, 0.3}},                                                     // down, up
+	3: {{0, 0}, {0.8, 0.1}, {0.4, 0.6}},                                         // down, right, up
+	4: {{0, 0}, {-0.7, 0.15}, {0.8, 0.15}, {0.4, 0.6}},                          // down, left, right, up
+	6: {{0, 0}, {-0.4, 0.1}, {-0.8, 0.15}, {0.4, 0.1}, {0.8, 0.15}, {0.3, 0.6}}, // down, left, left, right, right, up
+}
+
+// conductPattern returns the pattern for beats, or one that's spread them evenly
+// across, coming back up on the last, if there isn't a proper one.
+func conductPattern(beats int) [][2]float64 {
+	if p, ok := conductPoints[beats]; ok {
+		return p
+	}
+	p := make([][2]float64, beats)
+	for i := 1; i < beats-1; i++ {
+		p[i] = [2]float64{-0.8 + 1.6*float64(i-1)/float64(beats-3), 0.1}
+	}
+	p[beats-1] = [2]float64{0.4, 0.6}
+	return p
+}
+
+// conductView draws a conductor's baton, width x height, moving through the conducting
+// pattern for the signature: bouncing up from each beat and dropping onto the next.
+func (g tuiGnome) conductView(width, height int) string {
+	c := newCanvas(width, height)
+	beats := int(g.ctl.ts.Beats.Load())
+	if height < 4 || width < 10 || beats < 1 {
+		return c.String()
+	}
+
+	points := conductPattern(beats)
+	toCell := func(p [2]float64) (int, int) {
+		return width/2 + int(math.Round(p[0]*float64(width/2-2))), (height - 1) - int(math.Round(p[1]*float64(height-2)))
+	}
+
+	// Where the beats land, numbered
+	for i, p := range points {
+		x, y := toCell(p)
+		c.write(x, y, strconv.Itoa(i+1))
+	}
+
+	// From the last beat to the next, by way of a bounce above both, easing
+	// into the beat like gravity does.
+	from := points[max(g.beat-1, 0)%beats]
+	to := points[max(g.beat, 0)%beats]
+	bounce := [2]float64{(from[0] + to[0]) / 2, min(max(from[1], to[1])+0.5, 1)}
+	t := (1 - math.Cos(math.Pi*g.phase())) / 2
+	var at [2]float64
+	for i := range at {
+		at[i] = (1-t)*(1-t)*from[i] + 2*(1-t)*t*bounce[i] + t*t*to[i]
+	}
+	x, y := toCell(at)
+	c.set(x, y, '●')
+	return c.String()
+}
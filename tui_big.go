@@ -0,0 +1,60 @@
+//go:build !wasm
+
+package main
+
+import (
+	"strconv"
+	"strings"
+)
+
+// bigFont is a 3x5 font for the big beat display, '#' being ink.
+var bigFont = map[rune][5]string{
+	'0': {"###", "# #", "# #", "# #", "###"},
+	'1': {" # ", "## ", " # ", " # ", "###"},
+	'2': {"###", "  #", "###", "#  ", "###"},
+	'3': {"###", "  #", " ##", "  #", "###"},
+	'4': {"# #", "# #", "###", "  #", "  #"},
+	'5': {"###", "#  ", "###", "  #", "###"},
+	'6': {"###", "#  ", "###", "# #", "###"},
+	'7': {"###", "  #", "  #", "  #", "  #"},
+	'8': {"###", "# #", "###", "# #", "###"},
+	'9': {"###", "# #", "###", "  #", "###"},
+}
+
+// bigNumber draws n in bigFont, as large as fits in width x height cells.
+// Cells being about twice as tall as they are wide, each dot is twice as wide as it is tall.
+// If even the smallest won't fit, n is just n.
+func bigNumber(n, width, height int) string {
+	digits := strconv.Itoa(n)
+
+	// Each digit is 3 dots wide, with a dot between digits
+	dots := 4*len(digits) - 1
+	scale := min(height/5, width/(2*dots))
+	if scale < 1 {
+		return digits
+	}
+
+	var b strings.Builder
+	for row := range 5 {
+		var line strings.Builder
+		for i, d := range digits {
+			if i > 0 {
+				line.WriteString(strings.Repeat(" ", 2*scale))
+			}
+			for _, dot := range bigFont[d][row] {
+				ink := " "
+				if dot == '#' {
+					ink = "█"
+				}
+				line.WriteString(strings.Repeat(ink, 2*scale))
+			}
+		}
+		for range scale {
+			if b.Len() > 0 {
+				b.WriteString("\n")
+			}
+			b.WriteString(line.String())
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,128 @@
+//go:build !wasm
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// tuiConfig is the TUI's config file, e.g.
+//
+//	{
+//	  "keys": {"up": ["up", "."], "down": ["down", "e"], "tempo": ["enter"]},
+//	  "colors": {"accent": "#FFFF00", "flash": "#FFFFFF", "downbeat": "#FFFF00"}
+//	}
+//
+// Anything left out stays as it was.
+type tuiConfig struct {
+	Keys   map[string][]string `json:"keys"`   // action (see keyMap.actions) to keys
+	Colors tuiColors           `json:"colors"` // hex (#RRGGBB), or ANSI (0-255)
+}
+
+// tuiColors are the colors the TUI draws with.
+type tuiColors struct {
+	Accent   string `json:"accent"`   // input prompts, errors, and the big downbeat
+	Flash    string `json:"flash"`    // the background flash
+	Downbeat string `json:"downbeat"` // the background flash, on the downbeat
+	HelpKey  string `json:"helpKey"`  // keys, in the help
+	HelpDesc string `json:"helpDesc"` // what they do, in the help
+}
+
+// colors is what the TUI draws with, after loadTUIConfig. Empty help colors are the bubbles defaults.
+var colors = tuiColors{
+	Accent:   "#FF75B7",
+	Flash:    "#555555",
+	Downbeat: "#FF75B7",
+}
+
+// colorRE matches what lipgloss.Color takes that we take too.
+var colorRE = regexp.MustCompile(`^(#[0-9A-Fa-f]{6}|#[0-9A-Fa-f]{3}|[0-9]{1,3})$`)
+
+// defaultTUIConfigPath returns where the TUI config lives, if --tui-config doesn't say.
+func defaultTUIConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "metrognome", "tui.json")
+}
+
+// loadTUIConfig loads the TUI config at path into keys and colors. If path is empty the
+// default is used, and if that doesn't exist, that's fine too.
+func loadTUIConfig(path string) error {
+	optional := path == ""
+	if optional {
+		path = defaultTUIConfigPath()
+	}
+	if path == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(path)
+	if optional && errors.Is(err, fs.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	var c tuiConfig
+	if err := json.Unmarshal(data, &c); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	if err := keys.apply(c.Keys); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	if err := colors.apply(c.Colors); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// apply sets the colors in custom that are set, if they're all colors.
+func (c *tuiColors) apply(custom tuiColors) error {
+	for _, cc := range []struct {
+		name string
+		to   *string
+		from string
+	}{
+		{"accent", &c.Accent, custom.Accent},
+		{"flash", &c.Flash, custom.Flash},
+		{"downbeat", &c.Downbeat, custom.Downbeat},
+		{"helpKey", &c.HelpKey, custom.HelpKey},
+		{"helpDesc", &c.HelpDesc, custom.HelpDesc},
+	} {
+		if cc.from == "" {
+			continue
+		}
+		if !colorRE.MatchString(cc.from) {
+			return fmt.Errorf("color %q for %q is neither #RRGGBB nor 0-255", cc.from, cc.name)
+		}
+		if n, err := strconv.Atoi(cc.from); err == nil && n > 255 {
+			return fmt.Errorf("color %q for %q is neither #RRGGBB nor 0-255", cc.from, cc.name)
+		}
+		*cc.to = cc.from
+	}
+	return nil
+}
+
+// helpStyles restyles h with the help colors, if any, drawing with r.
+func (c tuiColors) helpStyles(h *help.Model, r *lipgloss.Renderer) {
+	if c.HelpKey != "" {
+		h.Styles.ShortKey = r.NewStyle().Foreground(lipgloss.Color(c.HelpKey))
+		h.Styles.FullKey = h.Styles.ShortKey
+	}
+	if c.HelpDesc != "" {
+		h.Styles.ShortDesc = r.NewStyle().Foreground(lipgloss.Color(c.HelpDesc))
+		h.Styles.FullDesc = h.Styles.ShortDesc
+	}
+}
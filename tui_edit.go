@@ -0,0 +1,254 @@
+//go:build !wasm
+
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// tuiEdit is what's being edited in the TUI, if anything.
+type tuiEdit int
+
+const (
+	editNone tuiEdit = iota
+	editTempo
+	editSignature
+	editPattern
+	editSound
+	editMarking
+	editStyle
+	editGap
+	editPractice
+)
+
+// String is the name of what's being edited, for the status line.
+func (e tuiEdit) String() string {
+	switch e {
+	case editTempo:
+		return "TEMPO"
+	case editSignature:
+		return "SIGNATURE"
+	case editPattern:
+		return "PATTERN"
+	case editSound:
+		return "SOUND"
+	case editMarking:
+		return "MARKING"
+	case editStyle:
+		return "STYLE"
+	case editGap:
+		return "GAPS"
+	case editPractice:
+		return "PRACTICE TIMER"
+	}
+	return ""
+}
+
+// picks returns true if e is picked from a list, rather than typed in.
+func (e tuiEdit) picks() bool {
+	return e == editSound || e == editMarking || e == editStyle
+}
+
+var (
+	editAccept = key.NewBinding(
+		key.WithKeys("enter"),
+		key.WithHelp("enter", "set"),
+	)
+	editCancel = key.NewBinding(
+		key.WithKeys("esc", "ctrl+c"),
+		key.WithHelp("esc", "cancel"),
+	)
+)
+
+// pickItem is something to pick, as the pickers list it.
+type pickItem struct {
+	value string // what picking it sets, and what filtering looks at
+	title string // what the list shows
+}
+
+func (i pickItem) FilterValue() string { return i.value }
+func (i pickItem) Title() string       { return i.title }
+func (i pickItem) Description() string { return "" }
+
+// newPicker returns a list to pick from, empty until startEdit fills it.
+func newPicker() list.Model {
+	d := list.NewDefaultDelegate()
+	d.ShowDescription = false
+	d.SetSpacing(0)
+
+	l := list.New(nil, d, 0, 0)
+	l.SetShowStatusBar(false)
+	return l
+}
+
+// pickItems returns what there is to pick from for e, its title, and what's picked now.
+func pickItems(e tuiEdit, s controlStatus) (items []list.Item, title, current string) {
+	switch e {
+	case editSound:
+		for _, name := range sounds.Keys() {
+			items = append(items, pickItem{value: name, title: name})
+		}
+		return items, "Sound", s.Sound
+	case editMarking:
+		for _, m := range tempoMarkings {
+			items = append(items, pickItem{value: m.Name, title: m.String()})
+		}
+		return items, "Tempo Marking", markingFor(s.Tempo)
+	case editStyle:
+		for _, style := range tempoStyles {
+			items = append(items, pickItem{value: style.Name, title: style.String()})
+		}
+		return items, "Style (/ to search)", ""
+	}
+	return nil, "", ""
+}
+
+// startEdit opens the editor for e, filled in with what it is now.
+func (g tuiGnome) startEdit(e tuiEdit) (tuiGnome, tea.Cmd) {
+	g.editing = e
+	g.editErr = ""
+	s := g.ctl.Status()
+
+	if e.picks() {
+		items, title, current := pickItems(e, s)
+		g.picker.ResetFilter()
+		g.picker.Title = title
+		cmd := g.picker.SetItems(items)
+		g.picker.Select(0)
+		for i, item := range items {
+			if item.(pickItem).value == current {
+				g.picker.Select(i)
+			}
+		}
+		return g, cmd
+	}
+
+	switch e {
+	case editTempo:
+		g.input.Prompt = "Tempo (BPM): "
+		g.input.SetValue(fmtTempo(s.Tempo))
+	case editSignature:
+		g.input.Prompt = "Signature (e.g. 7/8): "
+		g.input.SetValue(s.Signature)
+	case editPattern:
+		g.input.Prompt = "Pattern (e.g. 1,3): "
+		g.input.SetValue(s.Pattern)
+	case editGap:
+		g.input.Prompt = "Gaps (bars on, off, % dropped, e.g. 2 2 10%, or off): "
+		g.input.SetValue(fmtGap(s.GapPlay, s.GapSilent, s.GapRandom))
+	case editPractice:
+		g.input.Prompt = "Practice (minutes, or bar N, and a break, e.g. 10 2): "
+		g.input.SetValue(fmtPractice(time.Duration(s.PracticeFor)*time.Second, s.PracticeBars, time.Duration(s.PracticeBreak)*time.Second))
+	}
+	g.input.CursorEnd()
+	return g, g.input.Focus()
+}
+
+// updateEdit is Update, while editing.
+func (g tuiGnome) updateEdit(msg tea.Msg) (tuiGnome, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		// The picker has its own uses for enter and esc, while filtering.
+		filtering := g.editing.picks() && g.picker.FilterState() == list.Filtering
+
+		switch {
+		case key.Matches(msg, editCancel) && !filtering:
+			g.lastMessage = g.editing.String() + " UNCHANGED"
+			g.editing = editNone
+			g.input.Blur()
+			return g, nil
+
+		case key.Matches(msg, editAccept) && !filtering:
+			if err := g.applyEdit(); err != nil {
+				// Let them have another go
+				g.editErr = err.Error()
+				return g, nil
+			}
+			g.lastMessage = g.editing.String() + " SET"
+			g.editing = editNone
+			g.input.Blur()
+			return g, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	if g.editing.picks() {
+		g.picker, cmd = g.picker.Update(msg)
+	} else {
+		g.input, cmd = g.input.Update(msg)
+	}
+	return g, cmd
+}
+
+// applyEdit sets whatever's being edited to what they entered.
+func (g tuiGnome) applyEdit() error {
+	v := strings.TrimSpace(g.input.Value())
+	switch g.editing {
+	case editTempo:
+		bpm, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return fmt.Errorf("invalid tempo %q", v)
+		}
+		return g.ctl.SetTempo(bpm)
+	case editSignature:
+		return g.ctl.SetSignature(v)
+	case editPattern:
+		return g.ctl.SetPattern(v)
+	case editGap:
+		play, silent, random, err := parseGap(v)
+		if err != nil {
+			return err
+		}
+		return g.ctl.SetGap(play, silent, random)
+	case editPractice:
+		d, bars, brk, err := parsePractice(v)
+		if err != nil {
+			return err
+		}
+		return g.ctl.SetPractice(d, bars, brk)
+	case editSound, editMarking, editStyle:
+		item, ok := g.picker.SelectedItem().(pickItem)
+		switch {
+		case !ok:
+			return fmt.Errorf("nothing picked")
+		case g.editing == editMarking:
+			return g.ctl.SetMarking(item.value)
+		case g.editing == editStyle:
+			return g.ctl.SetStyle(item.value)
+		}
+		return g.ctl.SetSound(item.value)
+	}
+	return nil
+}
+
+// editView is View, while editing, under status.
+func (g tuiGnome) editView(status string) string {
+	if g.editing.picks() {
+		var errLine string
+		if g.editErr != "" {
+			errLine = g.inputStyle.Render(g.editErr) + "\n"
+		}
+		return "\n" + status + errLine + g.picker.View()
+	}
+
+	var errLine string
+	if g.editErr != "" {
+		errLine = "\n" + g.inputStyle.Render(g.editErr)
+	}
+	editHelp := g.help.ShortHelpView([]key.Binding{editAccept, editCancel})
+	return "\n" + status + "\n" + g.input.View() + errLine + "\n\n" + editHelp
+}
+
+// newTUIInput returns the text input the editors share.
+func newTUIInput() textinput.Model {
+	ti := textinput.New()
+	ti.CharLimit = 32
+	return ti
+}
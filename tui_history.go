@@ -0,0 +1,45 @@
+//go:build !wasm
+
+package main
+
+import (
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// showHistory loads the practice log, as the history subcommand prints it, to show until
+// the next key.
+func (g tuiGnome) showHistory() tuiGnome {
+	if g.ctl.history == "" {
+		g.lastMessage = "NO PRACTICE LOG"
+		return g
+	}
+	sessions, err := loadHistory(g.ctl.history, time.Time{})
+	if err != nil {
+		g.lastMessage = strings.ToUpper(err.Error())
+		return g
+	}
+
+	var b strings.Builder
+	printHistory(&b, sessions)
+	g.history = strings.Split(strings.TrimSuffix(b.String(), "\n"), "\n")
+	g.lastMessage = "HISTORY - ANY KEY"
+	return g
+}
+
+// historyKey is Update for keys, while the history is showing: any key puts it away,
+// and quitting still quits. It returns false if the key's for Update too.
+func (g tuiGnome) historyKey(msg tea.KeyMsg) (tuiGnome, bool) {
+	g.history = nil
+	g.lastMessage = ""
+	return g, !key.Matches(msg, g.keys.Quit)
+}
+
+// historyView is the practice log, the newest of it, if it's more than height lines.
+func (g tuiGnome) historyView(height int) string {
+	lines := g.history[max(len(g.history)-height, 0):]
+	return strings.Join(lines, "\n") + "\n"
+}
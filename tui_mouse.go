@@ -0,0 +1,169 @@
+//go:build !wasm
+
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// tuiButton is something at the top of the TUI to click, or scroll over.
+type tuiButton struct {
+	label string
+	on    bool                 // draw it lit up?
+	click func() string        // returns what to say in the status line, if clickable
+	wheel func(up bool) string // returns what to say in the status line, if scrollable
+}
+
+// buttons returns the rows of buttons at the top of the TUI, as things stand. Only what
+// the keys would let you do is there.
+func (g tuiGnome) buttons() [][]tuiButton {
+	s := g.ctl.Status()
+
+	var transport []tuiButton
+	if g.keys.Pause.Enabled() {
+		switch {
+		case !s.Running:
+			transport = append(transport, tuiButton{label: "[ Start ]", click: g.playPause})
+		case s.Paused:
+			transport = append(transport, tuiButton{label: "[ Resume ]", click: g.playPause})
+		default:
+			transport = append(transport, tuiButton{label: "[ Pause ]", click: g.playPause})
+		}
+	}
+	if g.keys.Up.Enabled() && g.keys.Down.Enabled() {
+		nudge := func(delta float64) string {
+			return errOr(g.ctl.NudgeTempo(delta), "TEMPO "+fmtTempoDelta(delta))
+		}
+		wheel := func(up bool) string {
+			if up {
+				return nudge(1)
+			}
+			return nudge(-1)
+		}
+		transport = append(transport,
+			tuiButton{label: "[ - ]", click: func() string { return nudge(-tempoDelta) }},
+			tuiButton{label: fmtTempo(s.Tempo) + " BPM", wheel: wheel},
+			tuiButton{label: "[ + ]", click: func() string { return nudge(tempoDelta) }},
+		)
+	}
+	if g.keys.Mute.Enabled() {
+		transport = append(transport, tuiButton{label: "[ Mute ]", on: s.Muted, click: func() string {
+			g.ctl.Mute()
+			return "MUTE"
+		}})
+	}
+	if g.keys.Pan.Enabled() {
+		transport = append(transport, tuiButton{label: "[ Pan ]", on: s.Panned, click: func() string {
+			g.ctl.Pan()
+			return "PAN"
+		}})
+	}
+
+	// The hit pattern, a beat at a time
+	states := patternStates(s.Pattern, g.ctl.ts.Beats.Load())
+	pattern := []tuiButton{{label: "Beats:"}}
+	for i, state := range states {
+		b := i + 1
+		beat := tuiButton{label: "[" + strconv.Itoa(b) + "]", on: state != beatOff}
+		if state == beatAccent {
+			beat.label = "[" + strconv.Itoa(b) + ">]"
+		}
+		if g.keys.Pattern.Enabled() {
+			// On and off, leaving accents to the GUI, or typing them in.
+			beat.click = func() string {
+				toggled := slices.Clone(states)
+				if state == beatOff {
+					toggled[i] = beatOn
+				} else {
+					toggled[i] = beatOff
+				}
+				return errOr(g.ctl.SetPattern(statesPattern(toggled)), fmt.Sprintf("BEAT %d", b))
+			}
+		}
+		pattern = append(pattern, beat)
+	}
+
+	return [][]tuiButton{transport, pattern}
+}
+
+// playPause starts the gnome if it isn't running, otherwise pauses or resumes it.
+func (g tuiGnome) playPause() string {
+	defer g.clock.reset()
+	if g.ctl.Status().Running {
+		return errOr(g.ctl.Pause(), "PAUSE")
+	}
+	return errOr(g.ctl.Start(), "STARTED")
+}
+
+// errOr returns err, shouted, or ok if there isn't one.
+func errOr(err error, ok string) string {
+	if err != nil {
+		return strings.ToUpper(err.Error())
+	}
+	return ok
+}
+
+// buttonsView draws the rows of buttons, each on its own line.
+func (g tuiGnome) buttonsView() string {
+	var b strings.Builder
+	for _, row := range g.buttons() {
+		labels := make([]string, len(row))
+		for i, button := range row {
+			labels[i] = button.label
+			if button.on {
+				labels[i] = g.inputStyle.Render(button.label)
+			}
+		}
+		b.WriteString(strings.Join(labels, " "))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// buttonAt returns the button at x, y on the screen, if there is one.
+func (g tuiGnome) buttonAt(x, y int) (tuiButton, bool) {
+	// The view starts with a blank line, then the buttons.
+	rows := g.buttons()
+	if y < 1 || y > len(rows) {
+		return tuiButton{}, false
+	}
+
+	var left int
+	for _, button := range rows[y-1] {
+		right := left + lipgloss.Width(button.label)
+		if x >= left && x < right {
+			return button, true
+		}
+		left = right + 1 // the space between
+	}
+	return tuiButton{}, false
+}
+
+// mouse is Update, for mouse events.
+func (g tuiGnome) mouse(msg tea.MouseMsg) tuiGnome {
+	button, ok := g.buttonAt(msg.X, msg.Y)
+	if !ok {
+		if g.game != nil && !g.game.done() && msg.Action == tea.MouseActionPress && msg.Button == tea.MouseButtonLeft {
+			// Tapping along
+			return g.gameTap(time.Now())
+		}
+		return g
+	}
+
+	switch {
+	case msg.Action == tea.MouseActionPress && msg.Button == tea.MouseButtonLeft && button.click != nil:
+		g.lastMessage = button.click()
+	case msg.Button == tea.MouseButtonWheelUp && button.wheel != nil:
+		g.lastMessage = button.wheel(true)
+	case msg.Button == tea.MouseButtonWheelDown && button.wheel != nil:
+		g.lastMessage = button.wheel(false)
+	}
+	return g
+}
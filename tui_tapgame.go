@@ -0,0 +1,76 @@
+//go:build !wasm
+
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// gameTap is what taps along, during a round of the tap-along game (see tapgame.go), as
+// well as clicking anywhere that isn't a button.
+var gameTap = key.NewBinding(
+	key.WithKeys(" ", "enter"),
+	key.WithHelp("space", "tap"),
+)
+
+// startGame starts a round of the tap-along game.
+func (g tuiGnome) startGame() tuiGnome {
+	g.game = &tapRound{taps: tapRoundTaps}
+	g.lastMessage = fmt.Sprintf("TAP ALONG - %d TAPS, SPACE TO TAP, %s TO STOP", tapRoundTaps, strings.ToUpper(g.keys.Game.Help().Key))
+	return g
+}
+
+// gameKey is Update for keys, during a round of the tap-along game, returning false if
+// it's not for the game.
+func (g tuiGnome) gameKey(msg tea.KeyMsg) (tuiGnome, bool) {
+	switch {
+	case g.game.done():
+		// Any key puts the summary away, and quitting still quits.
+		g.game = nil
+		g.lastMessage = "TAP ALONG OVER"
+		return g, !key.Matches(msg, g.keys.Quit)
+	case key.Matches(msg, gameTap):
+		return g.gameTap(time.Now()), true
+	case key.Matches(msg, g.keys.Game), msg.String() == "esc":
+		g.game.stop()
+		if len(g.game.offsets) == 0 {
+			g.game = nil
+			g.lastMessage = "TAP ALONG STOPPED"
+			return g, true
+		}
+		g.lastMessage = "TAP ALONG OVER - ANY KEY"
+		return g, true
+	}
+	return g, false
+}
+
+// gameTap scores a tap at at, and says how it went.
+func (g tuiGnome) gameTap(at time.Time) tuiGnome {
+	off, ok := g.game.tap(at, g.ctl.LastBeat(), g.ctl.Period())
+	if !ok {
+		g.lastMessage = "WAIT FOR THE BEAT"
+		return g
+	}
+	verdict := "ON THE BEAT"
+	switch {
+	case off < -tapOnIt:
+		verdict = "EARLY"
+	case off > tapOnIt:
+		verdict = "LATE"
+	}
+	g.lastMessage = fmt.Sprintf("TAP %d/%d - %s (%+dms) - STREAK %d", len(g.game.offsets), g.game.taps, verdict, off.Milliseconds(), g.game.streak)
+	if g.game.done() {
+		g.lastMessage = "TAP ALONG OVER - ANY KEY"
+	}
+	return g
+}
+
+// gameView is the round's summary, for when it's over.
+func (g tuiGnome) gameView() string {
+	return strings.Join(g.game.summary(), "\n") + "\n"
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cognusion/go-gnome"
+)
+
+// voicesFS has the bundled voices, a directory per language (see sounds/voices/README.md).
+//
+//go:embed sounds/voices
+var voicesFS embed.FS
+
+//go:generate go run voice_synth.go
+
+// voiceSubdivisions are what's said between the beats, and where, as a fraction of the beat.
+var voiceSubdivisions = map[string][]struct {
+	at   float64
+	word string
+}{
+	"":        nil,
+	"and":     {{0.5, "and"}},
+	"e-and-a": {{0.25, "e"}, {0.5, "and"}, {0.75, "a"}},
+}
+
+// voice counts the beats out loud, from a voice pack: 1.wav, 2.wav, and so on, and
+// and.wav, e.wav, and a.wav for the subdivisions. Beats it has no number for just click.
+// Goro-safe.
+type voice struct {
+	name  string
+	words map[string]pcm // by file name, less the .wav
+	only  bool           // instead of the click, rather than over it
+	sub   string         // which of voiceSubdivisions
+
+	mu     sync.Mutex
+	period time.Duration     // of the beats in cache
+	cache  map[string][]byte // WAV files, by beat and click
+	clicks map[string]pcm    // decoded sounds, by name
+}
+
+// loadVoice loads the voice pack name, which is a directory, one of ours in the config
+// directory (e.g. ~/.config/metrognome/voices/fr), or one of the bundled ones.
+func loadVoice(name string, only bool, sub string) (*voice, error) {
+	if _, ok := voiceSubdivisions[sub]; !ok {
+		return nil, fmt.Errorf("unknown subdivision %q, must be one of: and, e-and-a", sub)
+	}
+
+	var fsys fs.FS
+	if dir, err := os.UserConfigDir(); err == nil && !strings.ContainsAny(name, `/\`) {
+		if st, err := os.Stat(filepath.Join(dir, "metrognome", "voices", name)); err == nil && st.IsDir() {
+			fsys = os.DirFS(filepath.Join(dir, "metrognome", "voices", name))
+		}
+	}
+	if st, err := os.Stat(name); fsys == nil && err == nil && st.IsDir() {
+		fsys = os.DirFS(name)
+	}
+	if st, err := fs.Stat(voicesFS, path.Join("sounds/voices", name)); fsys == nil && err == nil && st.IsDir() {
+		fsys, _ = fs.Sub(voicesFS, path.Join("sounds/voices", name))
+	}
+	if fsys == nil {
+		return nil, fmt.Errorf("no voice %q, as a directory, or in the config directory, or bundled (%s)", name, strings.Join(bundledVoices(), ", "))
+	}
+
+	v := &voice{
+		name:   name,
+		words:  make(map[string]pcm),
+		only:   only,
+		sub:    sub,
+		cache:  make(map[string][]byte),
+		clicks: make(map[string]pcm),
+	}
+	files, err := fs.Glob(fsys, "*.wav")
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		data, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, err
+		}
+		p, err := decodeWAV(data)
+		if err != nil {
+			return nil, fmt.Errorf("voice %q, %s: %w", name, file, err)
+		}
+		v.words[strings.TrimSuffix(file, ".wav")] = p
+	}
+	if _, ok := v.words["1"]; !ok {
+		return nil, fmt.Errorf("voice %q can't even say 1.wav", name)
+	}
+	return v, nil
+}
+
+// bundledVoices returns the names of the bundled voices, if there are any, or "none".
+func bundledVoices() []string {
+	var names []string
+	entries, _ := voicesFS.ReadDir("sounds/voices")
+	for _, e := range entries {
+		if e.IsDir() {
+			names = append(names, e.Name())
+		}
+	}
+	if len(names) == 0 {
+		return []string{"none"}
+	}
+	return names
+}
+
+// beat returns the WAV file for beat, at period, with the count said over click (one of
+// the sounds), or instead of it.
+func (v *voice) beat(beat int, click string, period time.Duration) ([]byte, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if period != v.period {
+		// The subdivisions have moved.
+		v.period = period
+		clear(v.cache)
+	}
+	key := strconv.Itoa(beat) + "/" + click
+	if data, ok := v.cache[key]; ok {
+		return data, nil
+	}
+
+	number, ok := v.words[strconv.Itoa(beat)]
+	var p pcm
+	if !v.only || !ok {
+		c, err := v.click(click)
+		if err != nil {
+			return nil, err
+		}
+		p = p.mix(c, 0)
+	}
+	p = p.mix(number, 0)
+	for _, s := range voiceSubdivisions[v.sub] {
+		p = p.mix(v.words[s.word], time.Duration(s.at*float64(period)))
+	}
+
+	data := p.wav()
+	v.cache[key] = data
+	return data, nil
+}
+
+// click returns the named sound, decoded. Call it holding mu.
+func (v *voice) click(name string) (pcm, error) {
+	if p, ok := v.clicks[name]; ok {
+		return p, nil
+	}
+	data, ok := sounds[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown sound %q", name)
+	}
+	p, err := decodeWAV(*data)
+	if err != nil {
+		return nil, fmt.Errorf("sound %q: %w", name, err)
+	}
+	v.clicks[name] = p
+	return p, nil
+}
+
+// queueBeat has the gnome ready to play beat next: the sound, or the count-in's, or the
+// accent's, with the count said over it, or instead, if there's a voice. Call it holding mu.
+func (c *control) queueBeat(beat int, countIn bool) error {
+	c.next, c.nextCountIn = beat, countIn
+	click := c.sound
+	switch {
+	case countIn:
+		click = countInSound
+	case c.beats[beat] == beatAccent:
+		click = accentSound
+	}
+
+	if c.voice == nil {
+		if click == c.playing {
+			return nil
+		}
+		if err := c.replaceSound(click); err != nil {
+			return err
+		}
+		c.playing = click
+		return nil
+	}
+
+	data, err := c.voice.beat(beat, click, c.Period())
+	if err != nil {
+		return err
+	}
+	buff := gnome.RPool.Get()
+	buff.Reset(data)
+	c.playing = "" // a beat of its own
+	return c.g.ReplaceStreamerFromBuffer(buff)
+}
@@ -0,0 +1,257 @@
+//go:build ignore
+
+// voice_synth makes the bundled English voice, sounds/voices/en, with a little formant
+// synthesizer (the way speech chips did it), as go generate in voice.go. It's a robot, but
+// a clear one. A recorded voice sounds better, and takes its place (see sounds/voices/README.md).
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+)
+
+const rate = 44100
+
+// phone is a speech sound: its formants, and how much it's voiced, breathed, and hissed.
+type phone struct {
+	f   [3]float64 // the first three formants, Hz
+	bw  [3]float64 // and their bandwidths
+	av  float64    // voicing
+	ah  float64    // aspiration, which goes through the formants too
+	af  float64    // frication, which goes through its own filter
+	fc  float64    // its center, Hz
+	fbw float64    // and bandwidth
+}
+
+// vowel is a voiced phone with formants f1, f2, and f3, voiced at av.
+func vowel(f1, f2, f3, av float64) phone {
+	return phone{f: [3]float64{f1, f2, f3}, bw: [3]float64{80, 100, 150}, av: av}
+}
+
+// hiss is a voiceless fricative, hissing at af around fc.
+func hiss(af, fc, fbw float64) phone {
+	return phone{f: [3]float64{500, 1500, 2500}, bw: [3]float64{80, 100, 150}, af: af, fc: fc, fbw: fbw}
+}
+
+// phones are the sounds the words are made of, by (roughly) IPA.
+var phones = map[string]phone{
+	"w": vowel(290, 610, 2150, 0.6),
+	"ʌ": vowel(640, 1190, 2390, 1),
+	"n": {f: [3]float64{250, 1700, 2600}, bw: [3]float64{80, 300, 400}, av: 0.5},
+	"u": vowel(330, 1200, 2250, 1),
+	"r": vowel(310, 1060, 1380, 0.75),
+	"ɚ": vowel(470, 1350, 1690, 0.9),
+	"i": vowel(280, 2250, 2950, 1),
+	"ɔ": vowel(570, 840, 2410, 1),
+	"a": vowel(730, 1090, 2440, 1),
+	"ɪ": vowel(400, 1920, 2560, 1),
+	"ɛ": vowel(530, 1840, 2480, 1),
+	"ə": vowel(500, 1400, 2450, 0.9),
+	"e": vowel(480, 1950, 2600, 1),
+	"æ": vowel(660, 1720, 2410, 1),
+	"v": {f: [3]float64{220, 1100, 2080}, bw: [3]float64{80, 100, 150}, av: 0.45, af: 0.12, fc: 5000, fbw: 4000},
+	"s": hiss(0.5, 6500, 3000),
+	"f": hiss(0.3, 6000, 6000),
+	"θ": hiss(0.25, 6500, 5000),
+	"-": {f: [3]float64{500, 1500, 2500}, bw: [3]float64{80, 100, 150}}, // a stop's closure
+	"t": hiss(0.8, 4800, 3500),                                          // and its bursts
+	"k": {f: [3]float64{400, 1900, 2500}, bw: [3]float64{80, 100, 150}, ah: 0.2, af: 0.6, fc: 2500, fbw: 1500},
+	"d": hiss(0.3, 3500, 3000),
+	"ʰ": {f: [3]float64{330, 1200, 2250}, bw: [3]float64{200, 200, 250}, ah: 0.35}, // aspiration, into u
+	"ᵈ": {f: [3]float64{200, 1100, 2500}, bw: [3]float64{150, 300, 400}, av: 0.25}, // a voiced closure
+}
+
+// step is a phone, and how long to say it for.
+type step struct {
+	phone string
+	dur   float64 // seconds
+	glide float64 // to get there from the last one, if not the usual
+}
+
+// words are what the voice says, by file name.
+var words = map[string][]step{
+	"1":   {{"w", 0.07, 0}, {"ʌ", 0.16, 0.06}, {"n", 0.12, 0}},
+	"2":   {{"t", 0.012, 0}, {"ʰ", 0.05, 0}, {"u", 0.2, 0.05}},
+	"3":   {{"θ", 0.1, 0}, {"r", 0.06, 0}, {"i", 0.19, 0.06}},
+	"4":   {{"f", 0.1, 0}, {"ɔ", 0.15, 0}, {"ɚ", 0.12, 0.08}},
+	"5":   {{"f", 0.1, 0}, {"a", 0.12, 0}, {"ɪ", 0.1, 0.1}, {"v", 0.07, 0}},
+	"6":   {{"s", 0.12, 0}, {"ɪ", 0.1, 0}, {"-", 0.05, 0}, {"k", 0.015, 0}, {"s", 0.12, 0}},
+	"7":   {{"s", 0.11, 0}, {"ɛ", 0.1, 0}, {"v", 0.05, 0}, {"ə", 0.05, 0}, {"n", 0.11, 0}},
+	"8":   {{"e", 0.12, 0}, {"ɪ", 0.09, 0.09}, {"-", 0.04, 0}, {"t", 0.02, 0}},
+	"9":   {{"n", 0.08, 0}, {"a", 0.14, 0.04}, {"ɪ", 0.09, 0.09}, {"n", 0.11, 0}},
+	"and": {{"æ", 0.13, 0}, {"n", 0.07, 0}, {"ᵈ", 0.03, 0}, {"d", 0.01, 0}},
+	"e":   {{"i", 0.12, 0}},
+	"a":   {{"ə", 0.1, 0}},
+}
+
+func main() {
+	dir := filepath.Join("sounds", "voices", "en")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	for name, steps := range words {
+		if err := os.WriteFile(filepath.Join(dir, name+".wav"), wav(say(steps)), 0o644); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	}
+}
+
+// resonator is a two-pole filter, ringing at a frequency, as Klatt's synthesizer has them.
+type resonator struct {
+	y1, y2 float64
+}
+
+// next filters x, ringing at f with bandwidth bw, with a gain of one at DC.
+func (r *resonator) next(x, f, bw float64) float64 {
+	c := -math.Exp(-2 * math.Pi * bw / rate)
+	b := 2 * math.Exp(-math.Pi*bw/rate) * math.Cos(2*math.Pi*f/rate)
+	y := (1-b-c)*x + b*r.y1 + c*r.y2
+	r.y2, r.y1 = r.y1, y
+	return y
+}
+
+// bandpass is a band-pass filter with a gain of one at its center.
+type bandpass struct {
+	x1, x2, y1, y2 float64
+}
+
+// next filters x, passing fc with bandwidth bw.
+func (p *bandpass) next(x, fc, bw float64) float64 {
+	w := 2 * math.Pi * min(fc, rate/2.2) / rate
+	alpha := math.Sin(w) * math.Sinh(math.Ln2/2*(bw/fc)*w/math.Sin(w))
+	a0 := 1 + alpha
+	y := (alpha*x-alpha*p.x2)/a0 - (-2*math.Cos(w)*p.y1)/a0 - ((1-alpha)*p.y2)/a0
+	p.x2, p.x1 = p.x1, x
+	p.y2, p.y1 = p.y1, y
+	return y
+}
+
+// say synthesizes steps, in order, as 16-bit mono samples.
+func say(steps []step) []int16 {
+	var (
+		out          []float64
+		casc         [5]resonator
+		fric         [2]bandpass
+		phase, u1    float64
+		dcx, dcy     float64
+		rnd          = rand.New(rand.NewSource(1))
+		total, spent float64
+	)
+	for _, s := range steps {
+		total += s.dur
+	}
+
+	// Start from silence, and ring out into it.
+	last := phones[steps[0].phone]
+	last.av, last.ah, last.af = 0, 0, 0
+	end := last
+	end.f = phones[steps[len(steps)-1].phone].f
+	steps = append(steps, step{"", 0.03, 0})
+
+	for _, s := range steps {
+		to, ok := phones[s.phone]
+		if !ok {
+			to = end
+		}
+		glide := s.glide
+		if glide == 0 {
+			glide = min(0.04, s.dur)
+		}
+		ramp := min(0.012, s.dur/3)
+
+		n := int(s.dur * rate)
+		for i := range n {
+			t := float64(i) / rate
+			g := smooth(min(t/glide, 1))
+			a := smooth(min(t/ramp, 1))
+			mix := func(from, to float64, by float64) float64 { return from + (to-from)*by }
+
+			// The voice: falling through the word, as a statement does, with a little wobble
+			at := (spent + t) / total
+			f0 := mix(125, 95, min(at, 1)) * (1 + 0.006*math.Sin(2*math.Pi*5.5*(spent+t)))
+			phase += f0 / rate
+			if phase >= 1 {
+				phase--
+			}
+			const open = 0.6 // of each period, the glottis is
+			var u float64
+			if phase < open {
+				tau := phase / open
+				u = tau * tau * (1 - tau)
+			}
+			voiced := (u - u1) * open * rate / f0
+			u1 = u
+
+			noise := rnd.Float64()*2 - 1
+			x := mix(last.av, to.av, a)*voiced*4 + mix(last.ah, to.ah, a)*noise
+			for k := range 3 {
+				x = casc[k].next(x, mix(last.f[k], to.f[k], g), mix(last.bw[k], to.bw[k], g))
+			}
+			x = casc[3].next(x, 3500, 250)
+			x = casc[4].next(x, 4500, 300)
+
+			if af := mix(last.af, to.af, a); af > 0 {
+				fc, fbw := to.fc, to.fbw
+				if fc == 0 {
+					fc, fbw = last.fc, last.fbw
+				}
+				// Made up for what the filters leave out of the noise
+				x += 8 * af * fric[1].next(fric[0].next(noise, fc, fbw), fc, fbw)
+			}
+
+			// No DC
+			y := x - dcx + 0.995*dcy
+			dcx, dcy = x, y
+			out = append(out, y)
+		}
+		spent += s.dur
+		if ok {
+			last = to
+		} else {
+			last.av, last.ah, last.af = 0, 0, 0
+		}
+	}
+
+	// Half as loud as it goes, so the click still comes through
+	var peak float64
+	for _, x := range out {
+		peak = max(peak, math.Abs(x))
+	}
+	samples := make([]int16, len(out))
+	for i, x := range out {
+		samples[i] = int16(x / peak * 0.5 * math.MaxInt16)
+	}
+	return samples
+}
+
+// smooth eases x, from zero to one, in and out.
+func smooth(x float64) float64 {
+	return x * x * (3 - 2*x)
+}
+
+// wav returns samples as a 16-bit mono PCM WAV file.
+func wav(samples []int16) []byte {
+	data := make([]byte, 44+2*len(samples))
+	copy(data[0:], "RIFF")
+	binary.LittleEndian.PutUint32(data[4:], uint32(36+2*len(samples)))
+	copy(data[8:], "WAVEfmt ")
+	binary.LittleEndian.PutUint32(data[16:], 16)
+	binary.LittleEndian.PutUint16(data[20:], 1) // PCM
+	binary.LittleEndian.PutUint16(data[22:], 1) // mono
+	binary.LittleEndian.PutUint32(data[24:], rate)
+	binary.LittleEndian.PutUint32(data[28:], rate*2)
+	binary.LittleEndian.PutUint16(data[32:], 2)
+	binary.LittleEndian.PutUint16(data[34:], 16)
+	copy(data[36:], "data")
+	binary.LittleEndian.PutUint32(data[40:], uint32(2*len(samples)))
+	for i, s := range samples {
+		binary.LittleEndian.PutUint16(data[44+2*i:], uint16(s))
+	}
+	return data
+}
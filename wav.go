@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"time"
+)
+
+// sampleRate is the only one the gnome gets on with (see embeds.go).
+const sampleRate = 44100
+
+// pcm is 16-bit stereo sound at sampleRate, the left and right samples interleaved.
+type pcm []int16
+
+// decodeWAV returns the sound in a 16-bit PCM WAV file at sampleRate, in mono or stereo.
+func decodeWAV(data []byte) (pcm, error) {
+	if len(data) < 12 || string(data[0:4]) != "RIFF" || string(data[8:12]) != "WAVE" {
+		return nil, fmt.Errorf("not a WAV file")
+	}
+
+	var (
+		channels int
+		found    bool
+	)
+	for i := 12; i+8 <= len(data); {
+		id, size := string(data[i:i+4]), int(binary.LittleEndian.Uint32(data[i+4:i+8]))
+		body := data[i+8 : min(i+8+size, len(data))]
+		switch id {
+		case "fmt ":
+			if len(body) < 16 {
+				return nil, fmt.Errorf("short fmt chunk")
+			}
+			format := binary.LittleEndian.Uint16(body[0:2])
+			channels = int(binary.LittleEndian.Uint16(body[2:4]))
+			rate := binary.LittleEndian.Uint32(body[4:8])
+			bits := binary.LittleEndian.Uint16(body[14:16])
+			if format != 1 || bits != 16 || rate != sampleRate || channels < 1 || channels > 2 {
+				return nil, fmt.Errorf("must be 16-bit PCM at %d Hz, in mono or stereo", sampleRate)
+			}
+			found = true
+		case "data":
+			if !found {
+				return nil, fmt.Errorf("data before fmt")
+			}
+			frames := len(body) / (2 * channels)
+			p := make(pcm, 2*frames)
+			for f := range frames {
+				left := int16(binary.LittleEndian.Uint16(body[2*channels*f:]))
+				right := left
+				if channels == 2 {
+					right = int16(binary.LittleEndian.Uint16(body[2*channels*f+2:]))
+				}
+				p[2*f], p[2*f+1] = left, right
+			}
+			return p, nil
+		}
+		i += 8 + size + size&1 // chunks are padded to even sizes
+	}
+	return nil, fmt.Errorf("no data chunk")
+}
+
+// wav returns p as a WAV file.
+func (p pcm) wav() []byte {
+	data := make([]byte, 44+2*len(p))
+	copy(data[0:], "RIFF")
+	binary.LittleEndian.PutUint32(data[4:], uint32(36+2*len(p)))
+	copy(data[8:], "WAVEfmt ")
+	binary.LittleEndian.PutUint32(data[16:], 16)
+	binary.LittleEndian.PutUint16(data[20:], 1) // PCM
+	binary.LittleEndian.PutUint16(data[22:], 2) // stereo
+	binary.LittleEndian.PutUint32(data[24:], sampleRate)
+	binary.LittleEndian.PutUint32(data[28:], sampleRate*4)
+	binary.LittleEndian.PutUint16(data[32:], 4)
+	binary.LittleEndian.PutUint16(data[34:], 16)
+	copy(data[36:], "data")
+	binary.LittleEndian.PutUint32(data[40:], uint32(2*len(p)))
+	for i, s := range p {
+		binary.LittleEndian.PutUint16(data[44+2*i:], uint16(s))
+	}
+	return data
+}
+
+// mix returns p with src mixed in, starting at, and growing p if it needs to.
+func (p pcm) mix(src pcm, at time.Duration) pcm {
+	start := 2 * int(at.Seconds()*sampleRate)
+	if grow := start + len(src) - len(p); grow > 0 {
+		p = append(p, make(pcm, grow)...)
+	}
+	for i, s := range src {
+		p[start+i] = int16(max(min(int32(p[start+i])+int32(s), math.MaxInt16), math.MinInt16))
+	}
+	return p
+}